@@ -4,9 +4,9 @@ import (
 	"context"
 	"log"
 	"os"
+	"strconv"
 
 	"clean-arq-layout/internal/infrastructure/http/clients"
-	"clean-arq-layout/internal/workers"
 )
 
 func main() {
@@ -21,11 +21,14 @@ func main() {
 	serviceURL := os.Args[1]
 	inputCSV := os.Args[2]
 	outputCSV := os.Args[3]
-	
+
 	workers := 10
 	if len(os.Args) > 4 {
-		// Parsear número de workers si se proporciona
-		// Para simplicidad, usando valor por defecto
+		parsed, err := strconv.Atoi(os.Args[4])
+		if err != nil || parsed < 1 {
+			log.Fatalf("Invalid number of workers: %s", os.Args[4])
+		}
+		workers = parsed
 	}
 
 	log.Printf("Starting CSV offer cancellation processor")
@@ -38,7 +41,7 @@ func main() {
 	priceService := clients.NewPriceServiceHTTPClient(serviceURL, "batch_cancellation")
 
 	// Crear procesador CSV con el cliente inyectado
-	processor := worker.NewCSVProcessor(
+	processor := newCSVProcessor(
 		priceService,
 		inputCSV,
 		outputCSV,
@@ -52,4 +55,4 @@ func main() {
 	}
 
 	log.Println("CSV processing completed successfully!")
-}
\ No newline at end of file
+}
@@ -0,0 +1,299 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"clean-arq-layout/internal/domain/interfaces"
+	"clean-arq-layout/internal/workers"
+	"clean-arq-layout/internal/workers/jobs"
+	"clean-arq-layout/internal/workers/types"
+)
+
+const (
+	// csvQueueName es la cola del job que coordina el procesamiento del CSV, así
+	// no ocupa uno de los workers de las cancelaciones
+	csvQueueName = "csv"
+	// csvProcessingTimeout es el tiempo máximo para procesar un CSV completo
+	csvProcessingTimeout = time.Hour
+)
+
+// Estados de cada oferta en el CSV de salida
+const (
+	csvStatusSuccess      = "SUCCESS"
+	csvStatusError        = "ERROR"
+	csvStatusNotProcessed = "NOT_PROCESSED"
+)
+
+// csvOffer es una oferta leída del CSV de entrada
+type csvOffer struct {
+	Row     int
+	OfferID string
+}
+
+// csvProcessor cancela en lote las ofertas de un CSV y escribe el resultado
+// de cada una en otro CSV
+type csvProcessor struct {
+	priceService interfaces.PriceServiceClient
+	inputPath    string
+	outputPath   string
+	workers      int
+}
+
+// newCSVProcessor crea un procesador que cancela las ofertas de inputPath
+// con workers cancelaciones en paralelo y escribe los resultados en outputPath
+func newCSVProcessor(priceService interfaces.PriceServiceClient, inputPath, outputPath string, workers int) *csvProcessor {
+	return &csvProcessor{
+		priceService: priceService,
+		inputPath:    inputPath,
+		outputPath:   outputPath,
+		workers:      max(workers, 1),
+	}
+}
+
+// ProcessCSV procesa el CSV completo mostrando el avance en vivo. Las ofertas
+// que no se pudieron cancelar quedan como ERROR en el CSV de salida; solo
+// devuelve error si no se pudo leer o escribir un archivo o si se canceló ctx.
+func (p *csvProcessor) ProcessCSV(ctx context.Context) error {
+	offers, err := readCSVOffers(p.inputPath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Processing %d offer cancellations from %s", len(offers), p.inputPath)
+
+	dispatcher := worker.NewDispatcher(ctx, p.workers, p.workers,
+		worker.WithNamedQueue(csvQueueName, worker.QueueConfig{Workers: 1, Size: 1}),
+	)
+	if err := dispatcher.Start(); err != nil {
+		return fmt.Errorf("failed to start dispatcher: %w", err)
+	}
+	defer dispatcher.Stop()
+
+	job := &csvCancellationJob{
+		dispatcher:   dispatcher,
+		priceService: p.priceService,
+		offers:       offers,
+		outputPath:   p.outputPath,
+	}
+	handle, err := dispatcher.SubmitWithContext(ctx, job)
+	if err != nil {
+		return fmt.Errorf("failed to submit CSV job: %w", err)
+	}
+
+	// Mostrar el avance que informa el job cada vez que cambia el porcentaje
+	lastPercent := -1
+	for info := range handle.Watch(ctx) {
+		progress := info.Progress
+		if progress.Total == 0 || int(progress.Percent()) == lastPercent {
+			continue
+		}
+		lastPercent = int(progress.Percent())
+		log.Printf("Progress: %d/%d (%d%%) %s", progress.Current, progress.Total, lastPercent, progress.Message)
+	}
+
+	result, err := handle.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	if !result.Success {
+		return fmt.Errorf("CSV processing failed: %w", result.Error)
+	}
+
+	summary, _ := result.Data.(types.ResultSummary)
+	log.Printf("Processing complete. Results written to %s", p.outputPath)
+	log.Println("=== PROCESSING SUMMARY ===")
+	log.Printf("Total processed: %d", summary.Total)
+	log.Printf("Successful: %d", summary.Succeeded)
+	log.Printf("Failed: %d", summary.Failed)
+	log.Printf("Success rate: %.2f%%", summary.SuccessRate()*100)
+	if summary.Total > 0 {
+		log.Printf("Average duration: %v", summary.Duration/time.Duration(summary.Total))
+	}
+	return nil
+}
+
+// csvCancellationJob encola un jobs.OfferCancelJob por oferta e informa el
+// avance a medida que terminan. Una oferta repetida en el CSV se cancela una
+// sola vez y todas sus filas reciben el mismo resultado.
+type csvCancellationJob struct {
+	types.JobOutput[types.ResultSummary]
+	dispatcher   *worker.Dispatcher
+	priceService interfaces.PriceServiceClient
+	offers       []csvOffer
+	outputPath   string
+}
+
+// Execute implementa la interfaz Job
+func (j *csvCancellationJob) Execute(ctx context.Context) error {
+	total := int64(len(j.offers))
+	types.ReportProgress(ctx, 0, total, "cancelling offers")
+
+	// Un job por oferta, en orden de aparición. Cada uno envía su resultado a
+	// finished, que tiene lugar para todos: ningún worker se bloquea al avisar.
+	finished := make(chan types.JobResult, len(j.offers))
+	rows := make(map[string][]int)
+	cancellations := make([]*jobs.OfferCancelJob, 0, len(j.offers))
+	for i, offer := range j.offers {
+		job := jobs.NewOfferCancelJob(fmt.Sprintf("csv-offer-%d", len(cancellations)), offer.OfferID, j.priceService, finished)
+		if _, seen := rows[job.Name()]; !seen {
+			cancellations = append(cancellations, job)
+		}
+		rows[job.Name()] = append(rows[job.Name()], i)
+	}
+
+	// Encolar en otra goroutine: con la cola llena Submit espera a que se
+	// libere lugar y el avance se sigue informando mientras tanto
+	go func() {
+		for _, job := range cancellations {
+			if _, err := j.dispatcher.SubmitWithContext(ctx, job); err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				finished <- types.JobResult{JobName: job.Name(), Error: err, Timestamp: time.Now()}
+			}
+		}
+	}()
+
+	var summary types.ResultSummary
+	results := make(map[int]types.JobResult, len(j.offers))
+	for pending := len(cancellations); pending > 0 && ctx.Err() == nil; {
+		select {
+		case result := <-finished:
+			pending--
+			for _, index := range rows[result.JobName] {
+				results[index] = result
+				summary.Total++
+				summary.Duration += result.Duration
+				if result.Success {
+					summary.Succeeded++
+				} else {
+					summary.Failed++
+				}
+			}
+			message := fmt.Sprintf("%d succeeded, %d failed", summary.Succeeded, summary.Failed)
+			types.ReportProgress(ctx, int64(summary.Total), total, message)
+		case <-ctx.Done():
+		}
+	}
+
+	// Lo procesado se escribe aunque se haya cancelado el resto
+	if err := writeCSVResults(j.outputPath, j.offers, results); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	j.SetOutput(summary)
+	return nil
+}
+
+// Name implementa la interfaz Job
+func (j *csvCancellationJob) Name() string {
+	return "csv-offer-cancellation"
+}
+
+// Priority implementa la interfaz Job
+func (j *csvCancellationJob) Priority() int {
+	return 0
+}
+
+// QueueName implementa la interfaz QueuedJob
+func (j *csvCancellationJob) QueueName() string {
+	return csvQueueName
+}
+
+// Timeout implementa la interfaz TimeoutJob
+func (j *csvCancellationJob) Timeout() time.Duration {
+	return csvProcessingTimeout
+}
+
+// readCSVOffers lee los IDs de oferta de la columna offer_id del CSV
+func readCSVOffers(path string) ([]csvOffer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input CSV: %w", err)
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	column := -1
+	for i, name := range header {
+		if strings.TrimSpace(name) == "offer_id" {
+			column = i
+			break
+		}
+	}
+	if column < 0 {
+		return nil, fmt.Errorf("offer_id column not found in CSV")
+	}
+
+	offers := make([]csvOffer, 0)
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV row %d: %w", row, err)
+		}
+		if column >= len(record) || strings.TrimSpace(record[column]) == "" {
+			log.Printf("Skipping CSV row %d: missing offer_id", row)
+			continue
+		}
+		offers = append(offers, csvOffer{Row: row, OfferID: strings.TrimSpace(record[column])})
+	}
+	return offers, nil
+}
+
+// writeCSVResults escribe una fila por oferta con su resultado, indexado por
+// su posición en offers. Las ofertas sin resultado quedan como NOT_PROCESSED.
+func writeCSVResults(path string, offers []csvOffer, results map[int]types.JobResult) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output CSV: %w", err)
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	writer.Write([]string{"offer_id", "row", "status", "error_message", "duration_ms", "timestamp"})
+	for i, offer := range offers {
+		row := []string{offer.OfferID, strconv.Itoa(offer.Row), csvStatusNotProcessed, "", "", ""}
+
+		if result, ok := results[i]; ok {
+			row[2] = csvStatusSuccess
+			if !result.Success {
+				row[2] = csvStatusError
+				if result.Error != nil {
+					row[3] = result.Error.Error()
+				}
+			}
+			row[4] = strconv.FormatFloat(float64(result.Duration)/float64(time.Millisecond), 'f', 2, 64)
+			row[5] = result.Timestamp.Format(time.RFC3339)
+		}
+		writer.Write(row)
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to write output CSV: %w", err)
+	}
+	return nil
+}
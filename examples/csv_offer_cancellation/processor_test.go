@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// countingPriceService cuenta las cancelaciones por oferta
+type countingPriceService struct {
+	mu    sync.Mutex
+	calls map[string]int
+	fail  map[string]bool
+}
+
+func (s *countingPriceService) Cancel(ctx context.Context, offerID string) error {
+	s.mu.Lock()
+	s.calls[offerID]++
+	s.mu.Unlock()
+
+	time.Sleep(10 * time.Millisecond)
+	if s.fail[offerID] {
+		return types.Permanent(errors.New("offer not found"))
+	}
+	return nil
+}
+
+func TestProcessCSVCancelsEachOfferOnce(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.csv")
+	output := filepath.Join(dir, "output.csv")
+	assert.NoError(t, os.WriteFile(input, []byte("offer_id\nA\nB\nA\nC\n"), 0o644))
+
+	service := &countingPriceService{calls: make(map[string]int), fail: map[string]bool{"C": true}}
+	assert.NoError(t, newCSVProcessor(service, input, output, 2).ProcessCSV(context.Background()))
+
+	assert.Equal(t, map[string]int{"A": 1, "B": 1, "C": 1}, service.calls)
+
+	file, err := os.Open(output)
+	assert.NoError(t, err)
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	assert.NoError(t, err)
+
+	statuses := make([]string, 0, len(rows)-1)
+	for _, row := range rows[1:] {
+		statuses = append(statuses, row[0]+"="+row[2])
+	}
+	assert.Equal(t, []string{"A=SUCCESS", "B=SUCCESS", "A=SUCCESS", "C=ERROR"}, statuses)
+}
@@ -15,5 +15,3 @@ require (
 	github.com/stretchr/objx v0.5.2 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
-
-require go.uber.org/dig v1.18.1 // indirect
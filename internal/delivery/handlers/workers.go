@@ -0,0 +1,291 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	worker "clean-arq-layout/internal/workers"
+)
+
+// WorkersHandler expone endpoints de administración del dispatcher de workers
+type WorkersHandler struct {
+	dispatcher *worker.Dispatcher
+}
+
+// rateLimitRequest es el cuerpo para crear o ajustar un límite de tasa
+type rateLimitRequest struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// cancelJobsRequest es el cuerpo para cancelar jobs en bloque. Prefix filtra
+// por nombre de job; para cancelar todos los jobs en vuelo hay que pedirlo
+// explícitamente con All, así un cuerpo vacío no cancela nada.
+type cancelJobsRequest struct {
+	Prefix string `json:"prefix"`
+	All    bool   `json:"all"`
+}
+
+// NewWorkersHandler crea el handler de administración de workers
+func NewWorkersHandler(dispatcher *worker.Dispatcher) *WorkersHandler {
+	return &WorkersHandler{dispatcher: dispatcher}
+}
+
+// Register registra las rutas de administración en el mux
+func (h *WorkersHandler) Register(mux *http.ServeMux) {
+	mux.HandleFunc("GET /admin/workers/stats", h.Stats)
+	mux.HandleFunc("GET /admin/workers/jobs", h.ListJobs)
+	mux.HandleFunc("GET /admin/workers/jobs/{id}", h.JobStatus)
+	mux.HandleFunc("DELETE /admin/workers/jobs/{id}", h.CancelJob)
+	mux.HandleFunc("POST /admin/workers/jobs/cancel", h.CancelJobs)
+	mux.HandleFunc("GET /admin/workers/rate-limits", h.ListRateLimits)
+	mux.HandleFunc("PUT /admin/workers/rate-limits/{key}", h.SetRateLimit)
+	mux.HandleFunc("DELETE /admin/workers/rate-limits/{key}", h.DeleteRateLimit)
+	mux.HandleFunc("POST /admin/workers/pause", h.Pause)
+	mux.HandleFunc("POST /admin/workers/resume", h.Resume)
+	mux.HandleFunc("POST /admin/workers/queues/{name}/pause", h.PauseQueue)
+	mux.HandleFunc("POST /admin/workers/queues/{name}/resume", h.ResumeQueue)
+	mux.HandleFunc("PUT /admin/workers/paused-jobs/{prefix}", h.PauseJobs)
+	mux.HandleFunc("DELETE /admin/workers/paused-jobs/{prefix}", h.ResumeJobs)
+	mux.HandleFunc("GET /admin/workers/history", h.History)
+	mux.HandleFunc("GET /admin/workers/history/{id}", h.HistoryEntry)
+}
+
+// Stats devuelve las estadísticas del dispatcher
+func (h *WorkersHandler) Stats(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.dispatcher.Stats())
+}
+
+// ListJobs devuelve los jobs encolados o en ejecución
+func (h *WorkersHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.dispatcher.InFlight())
+}
+
+// JobStatus devuelve el estado de un job
+func (h *WorkersHandler) JobStatus(w http.ResponseWriter, r *http.Request) {
+	info, err := h.dispatcher.Status(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, info)
+}
+
+// CancelJob cancela un job encolado o en ejecución
+func (h *WorkersHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if _, err := h.dispatcher.Status(id); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	if err := h.dispatcher.Cancel(id); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// CancelJobs cancela en bloque los jobs en vuelo cuyo nombre empieza con el
+// prefijo, o todos si el cuerpo lo pide con "all": true
+func (h *WorkersHandler) CancelJobs(w http.ResponseWriter, r *http.Request) {
+	var body cancelJobsRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Prefix == "" && !body.All {
+		writeError(w, http.StatusBadRequest, `prefix is required (use "all": true to cancel every job)`)
+		return
+	}
+	if body.Prefix != "" && body.All {
+		writeError(w, http.StatusBadRequest, "prefix and all are mutually exclusive")
+		return
+	}
+
+	cancelled := h.dispatcher.CancelJobs(func(info worker.JobInfo) bool {
+		return strings.HasPrefix(info.JobName, body.Prefix)
+	})
+
+	writeJSON(w, http.StatusOK, map[string]int{"cancelled": cancelled})
+}
+
+// ListRateLimits devuelve los límites de tasa configurados
+func (h *WorkersHandler) ListRateLimits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.dispatcher.RateLimits())
+}
+
+// SetRateLimit crea o ajusta el límite de tasa de un recurso o prefijo de job
+func (h *WorkersHandler) SetRateLimit(w http.ResponseWriter, r *http.Request) {
+	var body rateLimitRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if err := h.dispatcher.SetRateLimit(r.PathValue("key"), body.Rate, body.Burst); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.dispatcher.RateLimits())
+}
+
+// DeleteRateLimit elimina el límite de tasa de un recurso o prefijo de job
+func (h *WorkersHandler) DeleteRateLimit(w http.ResponseWriter, r *http.Request) {
+	if !h.dispatcher.RemoveRateLimit(r.PathValue("key")) {
+		writeError(w, http.StatusNotFound, "rate limit not found")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Pause deja de entregar jobs en todas las colas
+func (h *WorkersHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	h.dispatcher.Pause()
+	writeJSON(w, http.StatusOK, h.dispatcher.Pauses())
+}
+
+// Resume vuelve a entregar jobs en todas las colas
+func (h *WorkersHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	h.dispatcher.Resume()
+	writeJSON(w, http.StatusOK, h.dispatcher.Pauses())
+}
+
+// PauseQueue deja de entregar los jobs de una cola
+func (h *WorkersHandler) PauseQueue(w http.ResponseWriter, r *http.Request) {
+	if err := h.dispatcher.PauseQueue(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.dispatcher.Pauses())
+}
+
+// ResumeQueue vuelve a entregar los jobs de una cola
+func (h *WorkersHandler) ResumeQueue(w http.ResponseWriter, r *http.Request) {
+	if err := h.dispatcher.ResumeQueue(r.PathValue("name")); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.dispatcher.Pauses())
+}
+
+// PauseJobs deja de ejecutar los jobs cuyo nombre empieza con el prefijo.
+// Responde 201 si el prefijo no estaba pausado y 200 si ya lo estaba.
+func (h *WorkersHandler) PauseJobs(w http.ResponseWriter, r *http.Request) {
+	status := http.StatusOK
+	if h.dispatcher.PauseJobs(r.PathValue("prefix")) {
+		status = http.StatusCreated
+	}
+	writeJSON(w, status, h.dispatcher.Pauses())
+}
+
+// ResumeJobs vuelve a ejecutar los jobs cuyo nombre empieza con el prefijo
+func (h *WorkersHandler) ResumeJobs(w http.ResponseWriter, r *http.Request) {
+	if !h.dispatcher.ResumeJobs(r.PathValue("prefix")) {
+		writeError(w, http.StatusNotFound, "paused jobs not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, h.dispatcher.Pauses())
+}
+
+// History devuelve los resultados de los jobs terminados. Filtra por los
+// parámetros job (prefijo del nombre), status, since, until (RFC 3339) y
+// limit; format=csv los exporta como CSV en lugar de JSON.
+func (h *WorkersHandler) History(w http.ResponseWriter, r *http.Request) {
+	query, err := parseHistoryQuery(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := h.dispatcher.History(query)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	// Si la exportación falla ya se envió el status: solo queda loguearlo
+	switch r.URL.Query().Get("format") {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		if err := worker.ExportHistoryJSON(w, entries); err != nil {
+			log.Printf("Failed to export job history as JSON: %v", err)
+		}
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="job_history.csv"`)
+		if err := worker.ExportHistoryCSV(w, entries); err != nil {
+			log.Printf("Failed to export job history as CSV: %v", err)
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "format must be json or csv")
+	}
+}
+
+// HistoryEntry devuelve el último resultado de un job terminado
+func (h *WorkersHandler) HistoryEntry(w http.ResponseWriter, r *http.Request) {
+	entry, err := h.dispatcher.HistoryEntry(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entry)
+}
+
+// parseHistoryQuery arma la consulta al historial con los parámetros de la URL
+func parseHistoryQuery(r *http.Request) (worker.HistoryQuery, error) {
+	params := r.URL.Query()
+	query := worker.HistoryQuery{
+		JobName: params.Get("job"),
+		Status:  worker.JobStatus(params.Get("status")),
+	}
+
+	switch query.Status {
+	case "", worker.JobQueued, worker.JobRunning, worker.JobSucceeded, worker.JobFailed, worker.JobCancelled:
+	default:
+		return query, fmt.Errorf("invalid status %q", query.Status)
+	}
+
+	var err error
+	if value := params.Get("since"); value != "" {
+		if query.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			return query, fmt.Errorf("invalid since: %w", err)
+		}
+	}
+	if value := params.Get("until"); value != "" {
+		if query.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			return query, fmt.Errorf("invalid until: %w", err)
+		}
+	}
+	if value := params.Get("limit"); value != "" {
+		if query.Limit, err = strconv.Atoi(value); err != nil || query.Limit < 0 {
+			return query, fmt.Errorf("invalid limit %q", value)
+		}
+	}
+	return query, nil
+}
+
+// writeJSON escribe la respuesta serializada como JSON
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+// writeError escribe un error con formato JSON
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	worker "clean-arq-layout/internal/workers"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// adminJob es un job configurable para probar los endpoints de administración
+type adminJob struct {
+	name string
+	run  func(ctx context.Context) error
+}
+
+func (j *adminJob) Execute(ctx context.Context) error {
+	if j.run == nil {
+		return nil
+	}
+	return j.run(ctx)
+}
+
+func (j *adminJob) Name() string  { return j.name }
+func (j *adminJob) Priority() int { return 0 }
+
+// newWorkersMux arranca un dispatcher y registra sus rutas de administración
+func newWorkersMux(t *testing.T, opts ...worker.DispatcherOption) (*worker.Dispatcher, *http.ServeMux) {
+	dispatcher := worker.NewDispatcher(context.Background(), 2, 10, opts...)
+	assert.NoError(t, dispatcher.Start())
+	t.Cleanup(dispatcher.Stop)
+
+	mux := http.NewServeMux()
+	NewWorkersHandler(dispatcher).Register(mux)
+	return dispatcher, mux
+}
+
+// serve ejecuta una request contra el mux y devuelve la respuesta grabada
+func serve(mux *http.ServeMux, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode deserializa el cuerpo JSON de la respuesta en un valor de tipo T
+func decode[T any](t *testing.T, rec *httptest.ResponseRecorder) T {
+	t.Helper()
+	var value T
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(&value))
+	return value
+}
+
+func TestWorkersHandlerRateLimits(t *testing.T) {
+	_, mux := newWorkersMux(t)
+
+	rec := serve(mux, http.MethodPut, "/admin/workers/rate-limits/price-", `{"rate": 5, "burst": 2}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	limits := decode[[]worker.RateLimitStatus](t, rec)
+	if assert.Len(t, limits, 1) {
+		assert.Equal(t, "price-", limits[0].Key)
+		assert.Equal(t, 5.0, limits[0].Rate)
+		assert.Equal(t, 2, limits[0].Burst)
+	}
+
+	rec = serve(mux, http.MethodGet, "/admin/workers/rate-limits", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Len(t, decode[[]worker.RateLimitStatus](t, rec), 1)
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/rate-limits/price-", "")
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/rate-limits/price-", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWorkersHandlerRateLimitsBadRequest(t *testing.T) {
+	_, mux := newWorkersMux(t)
+
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"invalid body", `{"rate": "fast"}`},
+		{"negative rate", `{"rate": -1, "burst": 1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(mux, http.MethodPut, "/admin/workers/rate-limits/price-", tt.body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.NotEmpty(t, decode[map[string]string](t, rec)["error"])
+		})
+	}
+
+	assert.Empty(t, decode[[]worker.RateLimitStatus](t, serve(mux, http.MethodGet, "/admin/workers/rate-limits", "")))
+}
+
+// blockingJob crea un job que corre hasta que lo cancelen
+func blockingJob(name string) *adminJob {
+	return &adminJob{name: name, run: func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}}
+}
+
+func TestWorkersHandlerJobStatus(t *testing.T) {
+	dispatcher, mux := newWorkersMux(t)
+
+	handle, err := dispatcher.Submit(&adminJob{name: "price-1"})
+	assert.NoError(t, err)
+	_, err = handle.Wait(context.Background())
+	assert.NoError(t, err)
+
+	rec := serve(mux, http.MethodGet, "/admin/workers/jobs/"+handle.ID(), "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	info := decode[worker.JobInfo](t, rec)
+	assert.Equal(t, handle.ID(), info.ID)
+	assert.Equal(t, worker.JobSucceeded, info.Status)
+
+	rec = serve(mux, http.MethodGet, "/admin/workers/jobs/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Contains(t, decode[map[string]string](t, rec)["error"], "not found")
+}
+
+func TestWorkersHandlerCancelJob(t *testing.T) {
+	dispatcher, mux := newWorkersMux(t)
+
+	running, err := dispatcher.Submit(blockingJob("price-1"))
+	assert.NoError(t, err)
+	finished, err := dispatcher.Submit(&adminJob{name: "price-2"})
+	assert.NoError(t, err)
+	_, err = finished.Wait(context.Background())
+	assert.NoError(t, err)
+
+	rec := serve(mux, http.MethodDelete, "/admin/workers/jobs/"+running.ID(), "")
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	result, err := running.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, result.Error, worker.ErrJobCancelled)
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/jobs/"+finished.ID(), "")
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/jobs/unknown", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWorkersHandlerCancelJobs(t *testing.T) {
+	dispatcher, mux := newWorkersMux(t)
+
+	for _, name := range []string{"price-1", "price-2", "report-1"} {
+		_, err := dispatcher.Submit(blockingJob(name))
+		assert.NoError(t, err)
+	}
+
+	t.Run("guard", func(t *testing.T) {
+		for _, body := range []string{"", "{}", `{"prefix": ""}`, `{"prefix": "price-", "all": true}`, "not json"} {
+			rec := serve(mux, http.MethodPost, "/admin/workers/jobs/cancel", body)
+			assert.Equal(t, http.StatusBadRequest, rec.Code, body)
+		}
+		assert.Len(t, dispatcher.InFlight(), 3, "a rejected request must not cancel anything")
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		rec := serve(mux, http.MethodPost, "/admin/workers/jobs/cancel", `{"prefix": "price-"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, map[string]int{"cancelled": 2}, decode[map[string]int](t, rec))
+		// Los cancelados en ejecución siguen en vuelo hasta que terminan
+		assert.Eventually(t, func() bool { return len(dispatcher.InFlight()) == 1 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("all", func(t *testing.T) {
+		rec := serve(mux, http.MethodPost, "/admin/workers/jobs/cancel", `{"all": true}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, map[string]int{"cancelled": 1}, decode[map[string]int](t, rec))
+	})
+}
+
+func TestWorkersHandlerPauseResume(t *testing.T) {
+	dispatcher, mux := newWorkersMux(t)
+
+	rec := serve(mux, http.MethodPost, "/admin/workers/pause", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, decode[worker.PauseStatus](t, rec).Paused)
+
+	handle, err := dispatcher.Submit(&adminJob{name: "price-1"})
+	assert.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, worker.JobQueued, handle.Status().Status)
+
+	rec = serve(mux, http.MethodPost, "/admin/workers/resume", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.False(t, decode[worker.PauseStatus](t, rec).Paused)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result, err := handle.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestWorkersHandlerPauseQueue(t *testing.T) {
+	_, mux := newWorkersMux(t)
+
+	rec := serve(mux, http.MethodPost, "/admin/workers/queues/"+worker.DefaultQueueName+"/pause", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{worker.DefaultQueueName}, decode[worker.PauseStatus](t, rec).Queues)
+
+	rec = serve(mux, http.MethodPost, "/admin/workers/queues/"+worker.DefaultQueueName+"/resume", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, decode[worker.PauseStatus](t, rec).Queues)
+
+	for _, action := range []string{"pause", "resume"} {
+		rec = serve(mux, http.MethodPost, "/admin/workers/queues/missing/"+action, "")
+		assert.Equal(t, http.StatusNotFound, rec.Code, action)
+	}
+}
+
+func TestWorkersHandlerPauseJobs(t *testing.T) {
+	_, mux := newWorkersMux(t)
+
+	rec := serve(mux, http.MethodPut, "/admin/workers/paused-jobs/price-", "")
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, []string{"price-"}, decode[worker.PauseStatus](t, rec).Jobs)
+
+	rec = serve(mux, http.MethodPut, "/admin/workers/paused-jobs/price-", "")
+	assert.Equal(t, http.StatusOK, rec.Code, "pausing an already paused prefix changes nothing")
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/paused-jobs/price-", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, decode[worker.PauseStatus](t, rec).Jobs)
+
+	rec = serve(mux, http.MethodDelete, "/admin/workers/paused-jobs/price-", "")
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestWorkersHandlerHistory(t *testing.T) {
+	dispatcher, mux := newWorkersMux(t)
+
+	succeeded, err := dispatcher.Submit(&adminJob{name: "price-1"})
+	assert.NoError(t, err)
+	_, err = dispatcher.Submit(&adminJob{name: "report-1", run: func(context.Context) error {
+		return errors.New("boom")
+	}})
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		entries, err := dispatcher.History(worker.HistoryQuery{})
+		return err == nil && len(entries) == 2
+	}, time.Second, 5*time.Millisecond)
+
+	t.Run("json", func(t *testing.T) {
+		rec := serve(mux, http.MethodGet, "/admin/workers/history", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+		assert.Len(t, decode[[]worker.HistoryEntry](t, rec), 2)
+	})
+
+	t.Run("filters", func(t *testing.T) {
+		since := url.QueryEscape(time.Now().Add(-time.Minute).Format(time.RFC3339))
+		rec := serve(mux, http.MethodGet, "/admin/workers/history?status=failed&since="+since, "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		entries := decode[[]worker.HistoryEntry](t, rec)
+		if assert.Len(t, entries, 1) {
+			assert.Equal(t, "report-1", entries[0].JobName)
+		}
+
+		rec = serve(mux, http.MethodGet, "/admin/workers/history?job=price-&limit=1", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Len(t, decode[[]worker.HistoryEntry](t, rec), 1)
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		rec := serve(mux, http.MethodGet, "/admin/workers/history?format=csv", "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, records, 3, "header and one row per entry")
+	})
+
+	t.Run("entry", func(t *testing.T) {
+		rec := serve(mux, http.MethodGet, "/admin/workers/history/"+succeeded.ID(), "")
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "price-1", decode[worker.HistoryEntry](t, rec).JobName)
+
+		rec = serve(mux, http.MethodGet, "/admin/workers/history/unknown", "")
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestWorkersHandlerHistoryBadRequest(t *testing.T) {
+	_, mux := newWorkersMux(t)
+
+	for _, query := range []string{
+		"limit=-1",
+		"limit=ten",
+		"format=xml",
+		"since=yesterday",
+		"until=2024-13-01",
+		"status=done",
+	} {
+		t.Run(query, func(t *testing.T) {
+			rec := serve(mux, http.MethodGet, "/admin/workers/history?"+query, "")
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.NotEmpty(t, decode[map[string]string](t, rec)["error"])
+		})
+	}
+}
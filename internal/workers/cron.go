@@ -0,0 +1,210 @@
+package worker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule calcula el próximo momento de ejecución posterior a t
+type Schedule interface {
+	Next(t time.Time) time.Time
+}
+
+// CronSchedule es una expresión cron estándar de 5 campos:
+// minuto, hora, día del mes, mes y día de la semana
+type CronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar/dowStar indican si el campo empieza con "*": si ambos días están
+	// restringidos alcanza con que coincida uno (semántica de cron clásico)
+	domStar, dowStar bool
+}
+
+// everySchedule ejecuta a intervalos fijos (@every <duración>)
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Truncate(time.Second).Add(s.interval)
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var (
+	minuteField = cronField{"minute", 0, 59}
+	hourField   = cronField{"hour", 0, 23}
+	domField    = cronField{"day of month", 1, 31}
+	monthField  = cronField{"month", 1, 12}
+	dowField    = cronField{"day of week", 0, 7}
+)
+
+// ParseSchedule interpreta una expresión cron de 5 campos, un descriptor
+// (@hourly, @daily, @weekly, @monthly, @yearly) o "@every <duración>"
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+
+	if strings.HasPrefix(spec, "@every ") {
+		interval, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid @every interval: %w", err)
+		}
+		if interval < time.Second {
+			return nil, fmt.Errorf("@every interval must be at least 1s")
+		}
+		return everySchedule{interval: interval}, nil
+	}
+
+	if expanded, ok := cronDescriptors[spec]; ok {
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields", spec)
+	}
+
+	schedule := &CronSchedule{
+		domStar: strings.HasPrefix(fields[2], "*"),
+		dowStar: strings.HasPrefix(fields[4], "*"),
+	}
+
+	var err error
+	if schedule.minute, err = parseCronField(fields[0], minuteField); err != nil {
+		return nil, err
+	}
+	if schedule.hour, err = parseCronField(fields[1], hourField); err != nil {
+		return nil, err
+	}
+	if schedule.dom, err = parseCronField(fields[2], domField); err != nil {
+		return nil, err
+	}
+	if schedule.month, err = parseCronField(fields[3], monthField); err != nil {
+		return nil, err
+	}
+	if schedule.dow, err = parseCronField(fields[4], dowField); err != nil {
+		return nil, err
+	}
+	// Se acepta 7 como domingo
+	if schedule.dow&(1<<7) != 0 {
+		schedule.dow = schedule.dow&^(1<<7) | 1
+	}
+
+	return schedule, nil
+}
+
+// parseCronField convierte un campo (listas, rangos y pasos) en un bitset
+func parseCronField(expr string, field cronField) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(expr, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			n, err := strconv.Atoi(part[idx+1:])
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step in %s field: %q", field.name, part)
+			}
+			step = n
+			part = part[:idx]
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err1, err2 error
+			low, err1 = strconv.Atoi(bounds[0])
+			high, err2 = strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil {
+				return 0, fmt.Errorf("invalid range in %s field: %q", field.name, part)
+			}
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value in %s field: %q", field.name, part)
+			}
+			low = n
+			if step == 1 {
+				high = n
+			}
+		}
+
+		if low < field.min || high > field.max || low > high {
+			return 0, fmt.Errorf("%s field out of range [%d-%d]: %q", field.name, field.min, field.max, part)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+
+	return bits, nil
+}
+
+// Next implementa Schedule buscando el próximo minuto que cumpla todos los campos
+func (s *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
+
+// dayMatches aplica la semántica clásica de cron para día del mes y de la semana
+func (s *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
@@ -0,0 +1,65 @@
+package worker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScheduleNext(t *testing.T) {
+	// Viernes
+	from := time.Date(2026, 10, 16, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, 10, 16, 10, 15, 0, 0, time.UTC)},
+		{"30 10 * * *", time.Date(2026, 10, 16, 10, 30, 0, 0, time.UTC)},
+		{"0,30 8-9 * * *", time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		// Con ambos días restringidos alcanza con que coincida uno
+		{"0 0 1 * 0", time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 17, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"@yearly", time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", time.Date(2026, 10, 16, 10, 9, 0, 0, time.UTC)},
+		// Una fecha que no existe nunca se cumple
+		{"0 0 31 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(from))
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		err  string
+	}{
+		{"* * * *", "must have 5 fields"},
+		{"60 * * * *", "minute field out of range"},
+		{"* 24 * * *", "hour field out of range"},
+		{"* * 0 * *", "day of month field out of range"},
+		{"5-1 * * * *", "minute field out of range"},
+		{"*/0 * * * *", "invalid step"},
+		{"a * * * *", "invalid value"},
+		{"1-b * * * *", "invalid range"},
+		{"@every 500ms", "at least 1s"},
+		{"@every soon", "invalid @every interval"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseSchedule(tt.spec)
+			assert.ErrorContains(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultDeadLetterCapacity es la cantidad de jobs que retiene el store en memoria por defecto
+const DefaultDeadLetterCapacity = 10000
+
+// DeadLetter es un job que agotó sus intentos y quedó apartado para inspección
+type DeadLetter struct {
+	ID         string
+	Job        Job
+	JobName    string
+	LastError  error
+	Attempts   int
+	EnqueuedAt time.Time
+	FailedAt   time.Time
+}
+
+// DeadLetterStore almacena los jobs fallidos para poder inspeccionarlos y reencolarlos
+type DeadLetterStore interface {
+	// Add guarda un job fallido
+	Add(entry DeadLetter) error
+	// Get devuelve el job fallido con el ID indicado
+	Get(id string) (DeadLetter, bool)
+	// List devuelve los jobs fallidos ordenados por fecha de fallo
+	List() []DeadLetter
+	// Remove elimina un job fallido y lo devuelve
+	Remove(id string) (DeadLetter, bool)
+	// Purge elimina todos los jobs fallidos y devuelve cuántos había
+	Purge() int
+	// Len devuelve la cantidad de jobs fallidos almacenados
+	Len() int
+}
+
+// MemoryDeadLetterStore es un DeadLetterStore en memoria con capacidad acotada.
+// Al llenarse descarta el job fallido más antiguo.
+type MemoryDeadLetterStore struct {
+	mu       sync.RWMutex
+	entries  map[string]DeadLetter
+	order    []string
+	capacity int
+}
+
+// NewMemoryDeadLetterStore crea un store en memoria (capacity <= 0 = sin límite)
+func NewMemoryDeadLetterStore(capacity int) *MemoryDeadLetterStore {
+	return &MemoryDeadLetterStore{
+		entries:  make(map[string]DeadLetter),
+		order:    make([]string, 0),
+		capacity: capacity,
+	}
+}
+
+// Add implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) Add(entry DeadLetter) error {
+	if entry.ID == "" {
+		return fmt.Errorf("dead letter entry requires an ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[entry.ID]; !exists {
+		s.order = append(s.order, entry.ID)
+	}
+	s.entries[entry.ID] = entry
+
+	for s.capacity > 0 && len(s.entries) > s.capacity {
+		oldest := s.order[0]
+		s.order = s.order[1:]
+		delete(s.entries, oldest)
+	}
+
+	return nil
+}
+
+// Get implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) Get(id string) (DeadLetter, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[id]
+	return entry, ok
+}
+
+// List implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) List() []DeadLetter {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]DeadLetter, 0, len(s.entries))
+	for _, entry := range s.entries {
+		entries = append(entries, entry)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].FailedAt.Before(entries[j].FailedAt)
+	})
+	return entries
+}
+
+// Remove implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) Remove(id string) (DeadLetter, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[id]
+	if !ok {
+		return DeadLetter{}, false
+	}
+
+	delete(s.entries, id)
+	for i, entryID := range s.order {
+		if entryID == id {
+			s.order = append(s.order[:i], s.order[i+1:]...)
+			break
+		}
+	}
+	return entry, true
+}
+
+// Purge implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) Purge() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.entries)
+	s.entries = make(map[string]DeadLetter)
+	s.order = s.order[:0]
+	return count
+}
+
+// Len implementa DeadLetterStore
+func (s *MemoryDeadLetterStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
@@ -3,8 +3,10 @@ package worker
 import (
 	"context"
 	"fmt"
+	"iter"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"clean-arq-layout/internal/workers/types"
@@ -13,25 +15,161 @@ import (
 // Job es un alias para mantener compatibilidad
 type Job = types.Job
 
+// RetryPolicy es un alias para configurar reintentos desde este paquete
+type RetryPolicy = types.RetryPolicy
+
+// DispatcherOption configura parámetros opcionales del dispatcher
+type DispatcherOption func(*dispatcherConfig)
+
+type dispatcherConfig struct {
+	poolOptions []PoolOption
+	scaling     *ScalingPolicy
+	deadLetters DeadLetterStore
+	history     HistoryStore
+	schedules   ScheduleStore
+	rateLimits  map[string]RateLimitStatus
+	queues      map[string]QueueConfig
+	// middleware y jobMiddleware envuelven la ejecución de los jobs de todas las colas
+	middleware    []Middleware
+	jobMiddleware []jobMiddleware
+	// propagators llevan valores del contexto de quien encola al del job
+	propagators []ContextPropagator
+	// historyRetention es la antigüedad máxima de los resultados en el historial
+	historyRetention time.Duration
+}
+
+// WithPoolOptions aplica opciones al pool de workers del dispatcher
+func WithPoolOptions(opts ...PoolOption) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.poolOptions = append(c.poolOptions, opts...)
+	}
+}
+
+// WithAutoscaling habilita el escalado automático de workers. Si la política
+// no define MaxWorkers se usa el maxWorkers del dispatcher.
+func WithAutoscaling(policy ScalingPolicy) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.scaling = &policy
+	}
+}
+
+// WithDeadLetterStore reemplaza el store en memoria donde se guardan los jobs
+// que agotaron sus intentos
+func WithDeadLetterStore(store DeadLetterStore) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.deadLetters = store
+	}
+}
+
+// WithScheduleStore define dónde se persisten las últimas ejecuciones de los
+// crons, necesario para detectar ejecuciones perdidas entre reinicios
+func WithScheduleStore(store ScheduleStore) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.schedules = store
+	}
+}
+
+// WithRateLimit configura un límite inicial de rate ejecuciones por segundo
+// (con ráfagas de hasta burst) para un recurso o prefijo de nombre de job
+func WithRateLimit(key string, rate float64, burst int) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		if c.rateLimits == nil {
+			c.rateLimits = make(map[string]RateLimitStatus)
+		}
+		c.rateLimits[key] = RateLimitStatus{Key: key, Rate: rate, Burst: burst}
+	}
+}
+
 // Dispatcher coordina y distribuye trabajos entre trabajadores
 type Dispatcher struct {
-	workerPool *Pool
-	ctx        context.Context
-	cancel     context.CancelFunc
-	wg         sync.WaitGroup
-	mu         sync.Mutex
-	started    bool
+	// workerPool es el pool de la cola por defecto; queues incluye además las colas con nombre
+	workerPool  *Pool
+	queues      *queueSet
+	scaler      *autoscaler
+	deadLetters DeadLetterStore
+	history     *historyWriter
+	scheduler   *scheduler
+	rateLimits  *RateLimiter
+	pauses      *pauseState
+	// retention es la antigüedad máxima de los resultados en el historial
+	retention time.Duration
+	ctx       context.Context
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+	mu        sync.Mutex
+	started   atomic.Bool
 }
 
-// NewDispatcher crea un nuevo dispatcher con un pool de workers
-func NewDispatcher(ctx context.Context, maxWorkers int, queueSize int) *Dispatcher {
+// NewDispatcher crea un nuevo dispatcher con un pool de workers. Un queueSize
+// <= 0 crea una cola por defecto sin límite, donde EnqueueJob nunca bloquea y
+// las políticas de overflow no se aplican.
+func NewDispatcher(ctx context.Context, maxWorkers int, queueSize int, opts ...DispatcherOption) *Dispatcher {
 	dispatcherCtx, cancel := context.WithCancel(ctx)
 
-	return &Dispatcher{
-		workerPool: NewWorkerPool(maxWorkers, queueSize),
-		ctx:        dispatcherCtx,
-		cancel:     cancel,
+	cfg := dispatcherConfig{}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.deadLetters == nil {
+		cfg.deadLetters = NewMemoryDeadLetterStore(DefaultDeadLetterCapacity)
+	}
+
+	if cfg.history == nil {
+		cfg.history = NewMemoryHistoryStore(DefaultHistoryCapacity)
+	}
+
+	rateLimits := NewRateLimiter()
+	for key, limit := range cfg.rateLimits {
+		if err := rateLimits.Set(key, limit.Rate, limit.Burst); err != nil {
+			log.Printf("Ignoring rate limit: %v", err)
+		}
+	}
+
+	pauses := newPauseState()
+	ordering := newKeySequencer()
+	history := newHistoryWriter(cfg.history)
+
+	// Opciones compartidas por los pools de todas las colas
+	shared := []PoolOption{
+		withDeadLetterStore(cfg.deadLetters),
+		withHistoryStore(history),
+		withRateLimiter(rateLimits),
+		withSharedMiddleware(cfg.middleware, cfg.jobMiddleware),
+		withPropagators(cfg.propagators),
+		withPauseState(pauses),
+		withKeySequencer(ordering),
+	}
+
+	poolOptions := append(cfg.poolOptions, shared...)
+	if cfg.scaling != nil {
+		if cfg.scaling.MaxWorkers > 0 {
+			maxWorkers = cfg.scaling.MaxWorkers
+		}
+		cfg.scaling.MaxWorkers = maxWorkers
+		poolOptions = append(poolOptions, WithMinWorkers(cfg.scaling.MinWorkers))
+	}
+
+	d := &Dispatcher{
+		workerPool:  NewWorkerPool(maxWorkers, queueSize, poolOptions...),
+		deadLetters: cfg.deadLetters,
+		history:     history,
+		rateLimits:  rateLimits,
+		retention:   cfg.historyRetention,
+		pauses:      pauses,
+		ctx:         dispatcherCtx,
+		cancel:      cancel,
+	}
+
+	d.queues = newQueueSet(d.workerPool, cfg.queues, shared)
+
+	if cfg.scaling != nil {
+		d.scaler = newAutoscaler(*cfg.scaling, d.workerPool)
 	}
+
+	d.scheduler = newScheduler(d.EnqueueJobContext, cfg.schedules)
+
+	return d
 }
 
 // Start inicia el dispatcher y sus workers
@@ -39,30 +177,175 @@ func (d *Dispatcher) Start() error {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if d.started {
+	if d.started.Load() {
 		return fmt.Errorf("dispatcher already started")
 	}
 
-	// Iniciar el pool de workers
-	if err := d.workerPool.Start(); err != nil {
-		return fmt.Errorf("failed to start worker pool: %w", err)
+	d.history.start()
+
+	// Iniciar el pool de cada cola (rehidrata la cola si es durable)
+	for _, name := range d.queues.names() {
+		if err := d.queues.pools[name].Start(); err != nil {
+			return fmt.Errorf("failed to start worker pool for queue %s: %w", name, err)
+		}
 	}
 
 	// Iniciar rutina de monitoreo usando waitgroup.Go
 	d.wg.Go(d.monitor)
 
-	d.started = true
+	// Iniciar el scheduler de jobs diferidos y recurrentes
+	d.wg.Go(func() { d.scheduler.run(d.ctx) })
+
+	d.started.Store(true)
 	log.Println("Dispatcher started successfully")
 	return nil
 }
 
-// EnqueueJob encola un trabajo para su procesamiento
+// EnqueueJob encola un trabajo para su procesamiento. No propaga ningún valor
+// de contexto (ni siquiera el ID de request): para encolar desde una request
+// usar EnqueueJobContext.
 func (d *Dispatcher) EnqueueJob(job Job) error {
-	if !d.started {
+	_, err := d.Submit(job)
+	return err
+}
+
+// EnqueueJobContext encola un trabajo esperando lugar como mucho hasta que
+// venza ctx, y propaga al job el ID de request y los valores de contexto
+// configurados con WithContextPropagation
+func (d *Dispatcher) EnqueueJobContext(ctx context.Context, job Job) error {
+	_, err := d.SubmitWithContext(ctx, job)
+	return err
+}
+
+// Submit encola un trabajo y devuelve un handle para consultar su estado,
+// esperarlo o cancelarlo. Con la cola llena se comporta según su política de
+// overflow. Como EnqueueJob, no propaga valores de contexto; desde una
+// request usar SubmitWithContext.
+func (d *Dispatcher) Submit(job Job) (*JobHandle, error) {
+	return d.submit(context.Background(), job, true)
+}
+
+// TrySubmit encola un trabajo sin bloquear: si su cola está llena y la
+// política es OverflowBlock devuelve ErrQueueFull en lugar de esperar
+func (d *Dispatcher) TrySubmit(job Job) (*JobHandle, error) {
+	return d.submit(context.Background(), job, false)
+}
+
+// SubmitWithContext encola un trabajo esperando lugar en su cola como mucho
+// hasta que venza ctx; pensado para handlers HTTP que no deben colgarse.
+// Propaga al job el ID de request y los valores de WithContextPropagation.
+func (d *Dispatcher) SubmitWithContext(ctx context.Context, job Job) (*JobHandle, error) {
+	return d.submit(ctx, job, true)
+}
+
+// submit enruta el trabajo a su cola y lo encola
+func (d *Dispatcher) submit(ctx context.Context, job Job, wait bool) (*JobHandle, error) {
+	if !d.started.Load() {
+		return nil, fmt.Errorf("dispatcher not started")
+	}
+
+	pool, err := d.queues.route(job)
+	if err != nil {
+		return nil, err
+	}
+	return pool.submit(ctx, job, wait)
+}
+
+// Status devuelve el estado de un job por su ID
+func (d *Dispatcher) Status(id string) (JobInfo, error) {
+	pool, ok := d.queues.find(id)
+	if !ok {
+		return JobInfo{}, fmt.Errorf("job %s not found", id)
+	}
+	return pool.Status(id)
+}
+
+// Watch recorre el estado de un job, incluido su avance, cada vez que cambia
+// hasta que termine o venza ctx
+func (d *Dispatcher) Watch(ctx context.Context, id string) (iter.Seq[JobInfo], error) {
+	pool, ok := d.queues.find(id)
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	return pool.Watch(ctx, id)
+}
+
+// Cancel quita un job de la cola o cancela su contexto si está en ejecución
+func (d *Dispatcher) Cancel(id string) error {
+	pool, ok := d.queues.find(id)
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+	if err := pool.Cancel(id); err != nil {
+		return err
+	}
+
+	log.Printf("Cancelled job %s", id)
+	return nil
+}
+
+// CancelJobs cancela los jobs en vuelo que cumplan el filtro (nil = todos) y
+// devuelve cuántos canceló
+func (d *Dispatcher) CancelJobs(filter func(JobInfo) bool) int {
+	cancelled := 0
+	for _, pool := range d.queues.all() {
+		for _, info := range pool.InFlight() {
+			if filter != nil && !filter(info) {
+				continue
+			}
+			if err := pool.Cancel(info.ID); err == nil {
+				cancelled++
+			}
+		}
+	}
+
+	log.Printf("Cancelled %d jobs", cancelled)
+	return cancelled
+}
+
+// InFlight devuelve los jobs encolados o en ejecución en todas las colas
+func (d *Dispatcher) InFlight() []JobInfo {
+	jobs := make([]JobInfo, 0)
+	for _, pool := range d.queues.all() {
+		jobs = append(jobs, pool.InFlight()...)
+	}
+	return jobs
+}
+
+// EnqueueAt encola un trabajo para que se procese a partir del momento indicado
+func (d *Dispatcher) EnqueueAt(job Job, at time.Time) error {
+	if !d.started.Load() {
 		return fmt.Errorf("dispatcher not started")
 	}
 
-	return d.workerPool.Submit(job)
+	if !at.After(time.Now()) {
+		return d.EnqueueJob(job)
+	}
+
+	d.scheduler.at(job, at)
+	return nil
+}
+
+// EnqueueAfter encola un trabajo para que se procese luego de la demora indicada
+func (d *Dispatcher) EnqueueAfter(job Job, delay time.Duration) error {
+	return d.EnqueueAt(job, time.Now().Add(delay))
+}
+
+// ScheduleCron registra un trabajo recurrente con una expresión cron de 5
+// campos, un descriptor (@hourly, @daily...) o "@every <duración>". El mismo
+// job se encola en cada ejecución, por lo que no debe guardar estado mutable.
+func (d *Dispatcher) ScheduleCron(name, spec string, job Job, opts ...CronOption) error {
+	if err := d.scheduler.addCron(name, spec, job, opts...); err != nil {
+		return fmt.Errorf("failed to schedule %s: %w", name, err)
+	}
+
+	log.Printf("Scheduled cron job %s (%s)", name, spec)
+	return nil
+}
+
+// Unschedule elimina un trabajo recurrente y devuelve si existía
+func (d *Dispatcher) Unschedule(name string) bool {
+	return d.scheduler.removeCron(name)
 }
 
 // Stop detiene el dispatcher y todos sus workers de manera ordenada
@@ -70,7 +353,7 @@ func (d *Dispatcher) Stop() {
 	d.mu.Lock()
 	defer d.mu.Unlock()
 
-	if !d.started {
+	if !d.started.Load() {
 		return
 	}
 
@@ -79,31 +362,88 @@ func (d *Dispatcher) Stop() {
 	// Señalar cancelación
 	d.cancel()
 
-	// Detener el pool de workers
-	d.workerPool.Stop()
+	// Detener los pools de todas las colas
+	for _, pool := range d.queues.all() {
+		pool.Stop()
+	}
 
 	// Esperar a que todas las goroutines de monitoreo terminen
 	d.wg.Wait()
+	d.history.stop()
 
-	d.started = false
+	d.started.Store(false)
 	log.Println("Dispatcher stopped")
 }
 
-// monitor es una rutina que podría monitorear el estado del sistema
-// y proporcionar métricas o ajustar dinámicamente el tamaño del pool
+// Drain deja de aceptar jobs, detiene el scheduler y espera a que terminen los
+// jobs encolados y en ejecución hasta que venza ctx. El reporte devuelve los
+// jobs abandonados y los diferidos que no llegaron a encolarse, para que el
+// caller los persista o los reencole en otra instancia.
+func (d *Dispatcher) Drain(ctx context.Context) (DrainReport, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.started.Load() {
+		return DrainReport{}, fmt.Errorf("dispatcher not started")
+	}
+
+	log.Println("Draining dispatcher...")
+
+	// Detener el scheduler y el monitor para que no lleguen jobs nuevos. Cancelar
+	// d.ctx libera al scheduler si está esperando lugar en una cola llena.
+	d.cancel()
+	stopped := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Println("Drain deadline reached while stopping the scheduler")
+	}
+	delayed := d.scheduler.takeDelayed()
+
+	report, err := d.queues.drain(ctx)
+	report.Delayed = delayed
+	d.history.stop()
+
+	d.started.Store(false)
+	if len(delayed) > 0 {
+		log.Printf("Dispatcher drained with %d delayed jobs pending", len(delayed))
+	}
+	log.Println("Dispatcher stopped")
+	return report, err
+}
+
+// monitor es una rutina que monitorea el estado del sistema y, si hay una
+// política de escalado, ajusta dinámicamente el tamaño del pool
 func (d *Dispatcher) monitor() {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
+	// Sin autoscaling el canal queda en nil y nunca se selecciona
+	var scaleTick <-chan time.Time
+	if d.scaler != nil {
+		scaleTicker := time.NewTicker(d.scaler.policy.CheckInterval)
+		defer scaleTicker.Stop()
+		scaleTick = scaleTicker.C
+	}
+
 	for {
 		select {
 		case <-ticker.C:
-			pending := d.workerPool.Pending()
-			log.Printf("Worker stats - Workers: %d, Pending jobs: %d",
-				d.workerPool.Size(), pending)
+			for name, queue := range d.queues.stats() {
+				log.Printf("Worker stats [%s] - Workers: %d, Pending jobs: %d, Running: %d, Throughput: %.2f jobs/s",
+					name, queue.Workers, queue.PendingJobs, queue.Metrics.InFlight, queue.Metrics.Throughput)
+			}
+			d.pruneHistory()
 
-			// Aquí podrías implementar lógica para escalar el número de workers
-			// basándote en la carga actual
+		case <-scaleTick:
+			if event := d.scaler.evaluate(); event != nil {
+				log.Printf("Worker pool scaled from %d to %d workers (%s)",
+					event.From, event.To, event.Reason)
+			}
 
 		case <-d.ctx.Done():
 			log.Println("Dispatcher monitor shutting down")
@@ -112,10 +452,142 @@ func (d *Dispatcher) monitor() {
 	}
 }
 
-func (d *Dispatcher) Stats() map[string]interface{} {
-	return map[string]interface{}{
-		"workers":      d.workerPool.Size(),
-		"pending_jobs": d.workerPool.Pending(),
-		"is_running":   d.started,
+// DispatcherStats es una copia del estado del dispatcher. Los totales suman
+// todas las colas; MinWorkers y MaxWorkers son los límites de la cola por
+// defecto, la única que escala.
+type DispatcherStats struct {
+	Running         bool                  `json:"is_running"`
+	Workers         int                   `json:"workers"`
+	IdleWorkers     int                   `json:"idle_workers"`
+	MinWorkers      int                   `json:"min_workers"`
+	MaxWorkers      int                   `json:"max_workers"`
+	PendingJobs     int                   `json:"pending_jobs"`
+	DeadLetters     int                   `json:"dead_letters"`
+	StuckJobs       []StuckJob            `json:"stuck_jobs"`
+	StuckDetections int64                 `json:"stuck_detections"`
+	DelayedJobs     int                   `json:"delayed_jobs"`
+	CronJobs        int                   `json:"cron_jobs"`
+	RateLimits      []RateLimitStatus     `json:"rate_limits"`
+	Pauses          PauseStatus           `json:"pauses"`
+	ScalingEvents   []ScalingEvent        `json:"scaling_events,omitempty"`
+	Metrics         PoolMetrics           `json:"metrics"`
+	Queues          map[string]QueueStats `json:"queues"`
+}
+
+// Stats devuelve el estado actual del dispatcher
+func (d *Dispatcher) Stats() DispatcherStats {
+	minWorkers, maxWorkers := d.workerPool.Bounds()
+
+	delayed, crons := d.scheduler.stats()
+
+	stats := DispatcherStats{
+		Running:     d.started.Load(),
+		MinWorkers:  minWorkers,
+		MaxWorkers:  maxWorkers,
+		DeadLetters: d.deadLetters.Len(),
+		StuckJobs:   make([]StuckJob, 0),
+		DelayedJobs: delayed,
+		CronJobs:    crons,
+		RateLimits:  d.rateLimits.Status(),
+		Pauses:      d.pauses.status(),
+		Queues:      d.queues.stats(),
 	}
+
+	metrics := make([]PoolMetrics, 0, len(stats.Queues))
+	for _, queue := range stats.Queues {
+		stats.Workers += queue.Workers
+		stats.IdleWorkers += queue.IdleWorkers
+		stats.PendingJobs += queue.PendingJobs
+		metrics = append(metrics, queue.Metrics)
+	}
+	stats.Metrics = mergeMetrics(metrics)
+
+	for _, pool := range d.queues.all() {
+		stats.StuckJobs = append(stats.StuckJobs, pool.StuckJobs()...)
+		stats.StuckDetections += pool.StuckDetections()
+	}
+
+	if d.scaler != nil {
+		stats.ScalingEvents = d.scaler.Events()
+	}
+
+	return stats
+}
+
+// DeadLetters devuelve los jobs que agotaron sus intentos
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	return d.deadLetters.List()
+}
+
+// DeadLetter devuelve un job fallido por su ID
+func (d *Dispatcher) DeadLetter(id string) (DeadLetter, error) {
+	entry, ok := d.deadLetters.Get(id)
+	if !ok {
+		return DeadLetter{}, fmt.Errorf("dead letter %s not found", id)
+	}
+	return entry, nil
+}
+
+// PurgeDeadLetters descarta todos los jobs fallidos y devuelve cuántos había
+func (d *Dispatcher) PurgeDeadLetters() int {
+	return d.deadLetters.Purge()
+}
+
+// ReplayDeadLetter vuelve a encolar un job fallido y lo quita del store. Con
+// la cola llena espera lugar como mucho hasta que venza ctx; si no entra, el
+// job vuelve al store.
+func (d *Dispatcher) ReplayDeadLetter(ctx context.Context, id string) error {
+	entry, ok := d.deadLetters.Remove(id)
+	if !ok {
+		return fmt.Errorf("dead letter %s not found", id)
+	}
+
+	if err := d.EnqueueJobContext(ctx, entry.Job); err != nil {
+		// Devolverlo al store para no perderlo
+		if addErr := d.deadLetters.Add(entry); addErr != nil {
+			log.Printf("Failed to restore dead-lettered job %s (%s): %v", entry.JobName, id, addErr)
+			return fmt.Errorf("failed to replay job %s: %w (and it could not be restored: %w)", entry.JobName, err, addErr)
+		}
+		return fmt.Errorf("failed to replay job %s: %w", entry.JobName, err)
+	}
+
+	log.Printf("Replayed dead-lettered job %s (%s)", entry.JobName, id)
+	return nil
+}
+
+// ReplayDeadLetters vuelve a encolar los jobs fallidos que cumplan el filtro
+// (nil = todos) y devuelve cuántos reencoló. Se detiene en el primero que no
+// entra antes de que venza ctx.
+func (d *Dispatcher) ReplayDeadLetters(ctx context.Context, filter func(DeadLetter) bool) (int, error) {
+	replayed := 0
+	for _, entry := range d.deadLetters.List() {
+		if filter != nil && !filter(entry) {
+			continue
+		}
+		if err := d.ReplayDeadLetter(ctx, entry.ID); err != nil {
+			return replayed, err
+		}
+		replayed++
+	}
+	return replayed, nil
+}
+
+// SetRateLimit crea o ajusta en caliente el límite de un recurso o prefijo de job
+func (d *Dispatcher) SetRateLimit(key string, rate float64, burst int) error {
+	if err := d.rateLimits.Set(key, rate, burst); err != nil {
+		return err
+	}
+
+	log.Printf("Rate limit for %s set to %.2f/s (burst %d)", key, rate, burst)
+	return nil
+}
+
+// RemoveRateLimit elimina el límite de un recurso o prefijo de job
+func (d *Dispatcher) RemoveRateLimit(key string) bool {
+	return d.rateLimits.Remove(key)
+}
+
+// RateLimits devuelve el estado de los límites configurados
+func (d *Dispatcher) RateLimits() []RateLimitStatus {
+	return d.rateLimits.Status()
 }
@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+var (
+	// ErrPoolDraining es el error de Submit mientras el pool se está drenando
+	ErrPoolDraining = errors.New("worker pool is draining")
+	// ErrJobAbandoned envuelve el error de los jobs interrumpidos por el cierre del pool
+	ErrJobAbandoned = errors.New("job abandoned on shutdown")
+)
+
+// DrainReport describe lo que quedó sin procesar al drenar el pool o el dispatcher
+type DrainReport struct {
+	// Abandoned son los jobs encolados o en ejecución que no terminaron antes
+	// del deadline. Con una cola durable además siguen persistidos en ella.
+	Abandoned []*QueueItem
+	// Delayed son los jobs diferidos que todavía no habían vencido (solo Dispatcher.Drain)
+	Delayed []DelayedJob
+}
+
+// Drain deja de aceptar jobs nuevos, espera a que terminen los encolados y en
+// ejecución y detiene el pool. Si ctx vence antes, cancela los jobs en
+// ejecución y devuelve en el reporte todo lo que quedó sin procesar junto con
+// el error de ctx.
+func (p *Pool) Drain(ctx context.Context) (DrainReport, error) {
+	if !p.started.Load() {
+		return DrainReport{}, fmt.Errorf("worker pool not started")
+	}
+	if p.draining.Swap(true) {
+		return DrainReport{}, fmt.Errorf("worker pool already draining")
+	}
+
+	log.Printf("Draining worker pool: %d jobs outstanding", p.outstanding.Load())
+
+	var err error
+	for err == nil && p.outstanding.Load() > 0 {
+		select {
+		case <-p.idle:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+	}
+
+	p.Stop()
+
+	p.abandonMu.Lock()
+	report := DrainReport{Abandoned: p.abandoned}
+	p.abandoned = nil
+	p.abandonMu.Unlock()
+
+	if err != nil {
+		log.Printf("Worker pool drain interrupted: %d jobs abandoned", len(report.Abandoned))
+		return report, fmt.Errorf("drain interrupted with %d jobs abandoned: %w", len(report.Abandoned), err)
+	}
+
+	log.Println("Worker pool drained")
+	return report, nil
+}
+
+// settleAbandoned junta lo que quedó sin procesar al detener el pool y lo
+// marca como abandonado, tanto en su estado como en el historial
+func (p *Pool) settleAbandoned() {
+	// Lo que esperaba su turno por clave de orden nunca llegó a la cola (con
+	// una cola durable sigue persistido)
+	for _, item := range p.ordering.takeAll(p) {
+		p.abandon(item)
+	}
+
+	// Lo retenido por una pausa o por su límite de tasa salió de la cola
+	// pero nunca se confirmó
+	for _, item := range append(p.held.takeAll(), p.throttled.takeAll()...) {
+		p.abandon(item)
+	}
+
+	// Lo que el dispatcher no llegó a entregar sigue en la cola cerrada
+	for {
+		item, err := p.jobQueue.Pop(p.ctx)
+		if err != nil {
+			break
+		}
+		p.abandon(item)
+	}
+	// y lo desbordado en el spill (persistido si es durable)
+	for p.spill != nil {
+		item, err := p.spill.Pop(p.ctx)
+		if err != nil {
+			break
+		}
+		p.abandon(item)
+	}
+
+	p.abandonMu.Lock()
+	abandoned := p.abandoned
+	// Solo Drain los reporta
+	if !p.draining.Load() {
+		p.abandoned = nil
+	}
+	p.abandonMu.Unlock()
+
+	for _, item := range abandoned {
+		p.finishAbandoned(item)
+	}
+}
+
+// finishAbandoned termina con ErrJobAbandoned un item que no se va a procesar,
+// tanto en su estado como en el historial
+func (p *Pool) finishAbandoned(item *QueueItem) {
+	result := types.JobResult{
+		JobID:     item.ID,
+		JobName:   item.Job.Name(),
+		Error:     ErrJobAbandoned,
+		Timestamp: time.Now(),
+	}
+	p.jobs.finish(item.ID, result)
+	p.recordHistory(item, result)
+}
+
+// abandon registra un item que no terminó por el cierre del pool
+func (p *Pool) abandon(item *QueueItem) {
+	p.abandonMu.Lock()
+	p.abandoned = append(p.abandoned, item)
+	p.abandonMu.Unlock()
+}
+
+// release descuenta un item terminado o descartado y avisa a Drain
+func (p *Pool) release() {
+	if p.outstanding.Add(-1) == 0 {
+		select {
+		case p.idle <- struct{}{}:
+		default:
+		}
+	}
+}
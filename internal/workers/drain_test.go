@@ -0,0 +1,57 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrainWaitsForQueuedJobs(t *testing.T) {
+	d := NewDispatcher(context.Background(), 2, 10)
+	assert.NoError(t, d.Start())
+
+	done := make(chan struct{}, 5)
+	for range 5 {
+		assert.NoError(t, d.EnqueueJob(newTestJob("job", func(context.Context) error {
+			time.Sleep(10 * time.Millisecond)
+			done <- struct{}{}
+			return nil
+		})))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	report, err := d.Drain(ctx)
+
+	assert.NoError(t, err)
+	assert.Empty(t, report.Abandoned)
+	assert.Len(t, done, 5)
+}
+
+func TestDrainDoesNotHangOnBlockedScheduler(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 1)
+	assert.NoError(t, d.Start())
+	d.Pause()
+
+	assert.NoError(t, d.EnqueueJob(newTestJob("queued", nil)))
+	assert.NoError(t, d.EnqueueAfter(newTestJob("delayed", nil), 10*time.Millisecond))
+	// Dar tiempo a que el scheduler quede esperando lugar en la cola llena
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	report, err := d.Drain(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+	if assert.Len(t, report.Abandoned, 1) {
+		assert.Equal(t, "queued", report.Abandoned[0].Job.Name())
+	}
+	if assert.Len(t, report.Delayed, 1) {
+		assert.Equal(t, "delayed", report.Delayed[0].Job.Name())
+	}
+}
@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileHistoryStore es un HistoryStore respaldado por un archivo con una entrada
+// JSON por línea. Las consultas se resuelven en memoria, así que conviene
+// acotarlo con WithHistoryRetention; Prune reescribe el archivo.
+type FileHistoryStore struct {
+	path string
+	mem  *MemoryHistoryStore
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+// NewFileHistoryStore crea (o abre) un historial en el path indicado,
+// cargando las entradas que ya tenga
+func NewFileHistoryStore(path string) (*FileHistoryStore, error) {
+	store := &FileHistoryStore{path: path, mem: NewMemoryHistoryStore(0)}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %w", err)
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Una línea truncada al final indica una escritura interrumpida
+			log.Printf("FileHistoryStore %s: skipping corrupt entry: %v", path, err)
+			continue
+		}
+		store.mem.entries = append(store.mem.entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to scan history file: %w", err)
+	}
+
+	store.file = file
+	return store, nil
+}
+
+// Add implementa HistoryStore agregando la entrada al final del archivo
+func (s *FileHistoryStore) Add(entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return fmt.Errorf("history store is closed")
+	}
+	if _, err := s.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return s.mem.Add(entry)
+}
+
+// Query implementa HistoryStore
+func (s *FileHistoryStore) Query(query HistoryQuery) ([]HistoryEntry, error) {
+	return s.mem.Query(query)
+}
+
+// Prune implementa HistoryStore reescribiendo el archivo de forma atómica
+// solo con las entradas que quedan. Las entradas en memoria se reemplazan
+// recién cuando el archivo nuevo quedó en su lugar, así un error deja la
+// memoria y el disco como estaban.
+func (s *FileHistoryStore) Prune(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return 0, fmt.Errorf("history store is closed")
+	}
+
+	// s.mu impide que se agreguen entradas mientras tanto
+	s.mem.mu.RLock()
+	kept := make([]HistoryEntry, 0, len(s.mem.entries))
+	for i := range s.mem.entries {
+		if entry := s.mem.at(i); !entry.FinishedAt.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+	pruned := len(s.mem.entries) - len(kept)
+	s.mem.mu.RUnlock()
+
+	if pruned == 0 {
+		return 0, nil
+	}
+
+	tmpPath := s.path + ".tmp"
+	tmp, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create history file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	encoder := json.NewEncoder(writer)
+	for _, entry := range kept {
+		if err := encoder.Encode(entry); err != nil {
+			tmp.Close()
+			return 0, fmt.Errorf("failed to encode history entry: %w", err)
+		}
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to write history file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return 0, fmt.Errorf("failed to sync history file: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return 0, fmt.Errorf("failed to replace history file: %w", err)
+	}
+
+	// Reabrir para seguir agregando sobre el archivo nuevo
+	file, err := os.OpenFile(s.path, os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to reopen history file: %w", err)
+	}
+	s.file.Close()
+	s.file = file
+
+	s.mem.mu.Lock()
+	s.mem.entries = kept
+	s.mem.start = 0
+	s.mem.mu.Unlock()
+
+	if err := syncDir(s.path); err != nil {
+		return pruned, fmt.Errorf("failed to sync history directory: %w", err)
+	}
+	return pruned, nil
+}
+
+// Close sincroniza y cierra el archivo del historial
+func (s *FileHistoryStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+
+	if err := s.file.Sync(); err != nil {
+		s.file.Close()
+		return fmt.Errorf("failed to sync history file: %w", err)
+	}
+	return s.file.Close()
+}
@@ -0,0 +1,73 @@
+package worker
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFilledHistoryStore crea un historial en archivo con n entradas, una por segundo desde start
+func newFilledHistoryStore(t *testing.T, path string, start time.Time, n int) *FileHistoryStore {
+	store, err := NewFileHistoryStore(path)
+	assert.NoError(t, err)
+	for i := range n {
+		entry := HistoryEntry{ID: fmt.Sprintf("job-%d", i), FinishedAt: start.Add(time.Duration(i) * time.Second)}
+		assert.NoError(t, store.Add(entry))
+	}
+	return store
+}
+
+func TestFileHistoryStorePrune(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.log")
+	start := time.Now().UTC()
+	store := newFilledHistoryStore(t, path, start, 4)
+
+	pruned, err := store.Prune(start.Add(2 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+	assert.NoError(t, store.Add(HistoryEntry{ID: "job-4", FinishedAt: start.Add(4 * time.Second)}))
+
+	entries, err := store.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-4", "job-3", "job-2"}, historyIDs(entries))
+	assert.NoError(t, store.Close())
+
+	// El archivo reescrito conserva lo mismo que la memoria
+	reopened, err := NewFileHistoryStore(path)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	entries, err = reopened.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-4", "job-3", "job-2"}, historyIDs(entries))
+}
+
+func TestFileHistoryStorePruneFailureKeepsEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.log")
+	start := time.Now().UTC()
+	store := newFilledHistoryStore(t, path, start, 3)
+	defer store.Close()
+
+	// Un directorio en lugar del archivo temporal hace fallar la reescritura
+	assert.NoError(t, os.Mkdir(path+".tmp", 0o755))
+
+	pruned, err := store.Prune(start.Add(2 * time.Second))
+	assert.Error(t, err)
+	assert.Zero(t, pruned)
+
+	entries, err := store.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-2", "job-1", "job-0"}, historyIDs(entries))
+
+	// Tras el error se sigue escribiendo en el archivo original
+	assert.NoError(t, store.Add(HistoryEntry{ID: "job-3", FinishedAt: start.Add(3 * time.Second)}))
+	reopened, err := NewFileHistoryStore(path)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	entries, err = reopened.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-3", "job-2", "job-1", "job-0"}, historyIDs(entries))
+}
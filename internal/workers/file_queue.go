@@ -0,0 +1,499 @@
+package worker
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SyncPolicy define cuándo se fuerza la escritura del log a disco
+type SyncPolicy int
+
+const (
+	// SyncAlways hace fsync después de cada escritura (más seguro, más lento)
+	SyncAlways SyncPolicy = iota
+	// SyncInterval hace fsync periódicamente en segundo plano
+	SyncInterval
+	// SyncNever delega en el sistema operativo cuándo escribir a disco
+	SyncNever
+)
+
+const (
+	defaultSyncInterval     = time.Second
+	defaultCompactThreshold = 1000
+)
+
+const (
+	recordPush = "push"
+	recordAck  = "ack"
+)
+
+// logRecord es una línea del log append-only de la cola
+type logRecord struct {
+	Op         string    `json:"op"`
+	ID         string    `json:"id"`
+	Type       string    `json:"type,omitempty"`
+	Payload    []byte    `json:"payload,omitempty"`
+	Priority   int       `json:"priority,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at,omitempty"`
+	RequestID  string    `json:"request_id,omitempty"`
+}
+
+// FileQueueOption configura parámetros opcionales de la FileQueue
+type FileQueueOption func(*FileQueue)
+
+// WithSyncPolicy define la política de fsync y el intervalo para SyncInterval
+func WithSyncPolicy(policy SyncPolicy, interval time.Duration) FileQueueOption {
+	return func(q *FileQueue) {
+		q.syncPolicy = policy
+		if interval > 0 {
+			q.syncInterval = interval
+		}
+	}
+}
+
+// WithCompactThreshold define cuántos registros obsoletos se toleran antes de compactar
+func WithCompactThreshold(threshold int) FileQueueOption {
+	return func(q *FileQueue) {
+		q.compactThreshold = threshold
+	}
+}
+
+// WithMemoryFallback hace que Push encole solo en memoria los jobs que no se
+// pueden serializar, en lugar de rechazarlos. Esos jobs no sobreviven un
+// reinicio: usarlo solo si se aceptan jobs que no son durables.
+func WithMemoryFallback() FileQueueOption {
+	return func(q *FileQueue) {
+		q.memoryFallback = true
+	}
+}
+
+// WithFileQueueAging define el intervalo de envejecimiento de la cola en memoria
+func WithFileQueueAging(interval time.Duration) FileQueueOption {
+	return func(q *FileQueue) {
+		q.agingInterval = interval
+	}
+}
+
+// FileQueue es una cola de prioridad respaldada por un log append-only en disco.
+//
+// Cada job encolado se escribe como un registro "push" y cada job terminado
+// como un registro "ack". Al recuperar, los push sin ack se vuelven a encolar,
+// por lo que los jobs que estaban en ejecución durante una caída se reintentan
+// (semántica at-least-once). Cuando los registros obsoletos superan el umbral,
+// el log se reescribe solo con los jobs pendientes.
+type FileQueue struct {
+	mem              *PriorityQueue
+	registry         *JobRegistry
+	path             string
+	syncPolicy       SyncPolicy
+	syncInterval     time.Duration
+	compactThreshold int
+	agingInterval    time.Duration
+	// memoryFallback encola en memoria los jobs que no se pueden serializar
+	memoryFallback bool
+
+	mu       sync.Mutex
+	file     *os.File
+	live     map[string]logRecord
+	order    []string
+	obsolete int
+	dirty    bool
+	closed   bool
+
+	stopSync chan struct{}
+	syncDone chan struct{}
+}
+
+// NewFileQueue crea (o abre) una cola durable en el path indicado
+func NewFileQueue(path string, capacity int, registry *JobRegistry, opts ...FileQueueOption) (*FileQueue, error) {
+	q := &FileQueue{
+		registry:         registry,
+		path:             path,
+		syncPolicy:       SyncAlways,
+		syncInterval:     defaultSyncInterval,
+		compactThreshold: defaultCompactThreshold,
+		agingInterval:    DefaultAgingInterval,
+		live:             make(map[string]logRecord),
+	}
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	q.mem = NewPriorityQueue(capacity, q.agingInterval)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open queue file: %w", err)
+	}
+	q.file = file
+
+	if q.syncPolicy == SyncInterval {
+		q.stopSync = make(chan struct{})
+		q.syncDone = make(chan struct{})
+		go q.syncLoop()
+	}
+
+	return q, nil
+}
+
+// Recover lee el log y vuelve a encolar los jobs que no fueron confirmados
+func (q *FileQueue) Recover() ([]*QueueItem, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, err := q.file.Seek(0, 0); err != nil {
+		return nil, fmt.Errorf("failed to read queue file: %w", err)
+	}
+
+	live := make(map[string]logRecord)
+	order := make([]string, 0)
+
+	scanner := bufio.NewScanner(q.file)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			// Una línea truncada al final indica una escritura interrumpida
+			log.Printf("FileQueue %s: skipping corrupt record: %v", q.path, err)
+			continue
+		}
+
+		switch record.Op {
+		case recordPush:
+			live[record.ID] = record
+			order = append(order, record.ID)
+		case recordAck:
+			delete(live, record.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan queue file: %w", err)
+	}
+
+	recovered := make([]*QueueItem, 0)
+	q.order = q.order[:0]
+	for _, id := range order {
+		record, ok := live[id]
+		if !ok {
+			continue
+		}
+		q.live[id] = record
+		q.order = append(q.order, id)
+
+		job, err := q.registry.Decode(record.Type, record.Payload)
+		if err != nil {
+			// Se conserva en el log para poder recuperarlo cuando se registre el tipo
+			log.Printf("FileQueue %s: cannot restore job %s: %v", q.path, id, err)
+			continue
+		}
+
+		item := &QueueItem{
+			ID:         record.ID,
+			Job:        job,
+			Priority:   record.Priority,
+			EnqueuedAt: record.EnqueuedAt,
+			RequestID:  record.RequestID,
+		}
+		q.mem.forcePush(item)
+		recovered = append(recovered, item)
+	}
+
+	if err := q.compact(); err != nil {
+		return recovered, err
+	}
+
+	return recovered, nil
+}
+
+// Push persiste el item y lo encola en memoria. El lugar en la cola se aparta
+// antes de escribir el log, así un item rechazado por la capacidad nunca se
+// persiste y no reaparece al recuperar. Un job que no se puede serializar se
+// rechaza, salvo con WithMemoryFallback.
+func (q *FileQueue) Push(ctx context.Context, item *QueueItem) error {
+	jobType, payload, err := q.registry.Encode(item.Job)
+	if err != nil {
+		if !q.memoryFallback {
+			return fmt.Errorf("job is not durable: %w", err)
+		}
+		log.Printf("FileQueue %s: %v, it will not survive a restart", q.path, err)
+		return q.mem.Push(ctx, item)
+	}
+
+	if err := q.mem.reserve(ctx); err != nil {
+		return err
+	}
+
+	if err := q.persist(item, jobType, payload); err != nil {
+		q.mem.unreserve()
+		return err
+	}
+
+	q.mem.pushReserved(item)
+	return nil
+}
+
+// Persist escribe el item en el log sin encolarlo ni ocupar capacidad. Lo
+// usa el pool para los jobs que esperan su turno por clave de orden: se
+// recuperan en un reinicio y se encolan después con PushPersisted.
+func (q *FileQueue) Persist(item *QueueItem) error {
+	jobType, payload, err := q.registry.Encode(item.Job)
+	if err != nil {
+		if !q.memoryFallback {
+			return fmt.Errorf("job is not durable: %w", err)
+		}
+		log.Printf("FileQueue %s: %v, it will not survive a restart", q.path, err)
+		return nil
+	}
+	return q.persist(item, jobType, payload)
+}
+
+// PushPersisted encola en memoria un item ya escrito con Persist, bloqueando
+// mientras la cola esté llena
+func (q *FileQueue) PushPersisted(ctx context.Context, item *QueueItem) error {
+	return q.mem.Push(ctx, item)
+}
+
+// persist agrega el registro push del item al log
+func (q *FileQueue) persist(item *QueueItem, jobType string, payload []byte) error {
+	record := logRecord{
+		Op:         recordPush,
+		ID:         item.ID,
+		Type:       jobType,
+		Payload:    payload,
+		Priority:   item.Priority,
+		EnqueuedAt: item.EnqueuedAt,
+		RequestID:  item.RequestID,
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if err := q.append(record); err != nil {
+		return err
+	}
+	q.live[item.ID] = record
+	q.order = append(q.order, item.ID)
+	return nil
+}
+
+// Pop extrae el siguiente item de la cola en memoria
+func (q *FileQueue) Pop(ctx context.Context) (*QueueItem, error) {
+	return q.mem.Pop(ctx)
+}
+
+// PopFunc extrae el siguiente item de la cola en memoria que cumpla eligible
+func (q *FileQueue) PopFunc(ctx context.Context, eligible func(*QueueItem) bool) (*QueueItem, error) {
+	return q.mem.PopFunc(ctx, eligible)
+}
+
+// TakeFunc extrae de la cola en memoria los items que cumplan match. Siguen
+// persistidos hasta su Ack.
+func (q *FileQueue) TakeFunc(match func(*QueueItem) bool) []*QueueItem {
+	return q.mem.TakeFunc(match)
+}
+
+// Ack registra que el item terminó y compacta el log si corresponde
+func (q *FileQueue) Ack(item *QueueItem) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.live[item.ID]; !ok || q.closed {
+		return nil
+	}
+
+	if err := q.append(logRecord{Op: recordAck, ID: item.ID}); err != nil {
+		return err
+	}
+	delete(q.live, item.ID)
+	q.obsolete += 2
+
+	if q.obsolete >= q.compactThreshold && q.obsolete > len(q.live) {
+		return q.compact()
+	}
+	return nil
+}
+
+// Remove quita un item pendiente y registra su descarte en el log
+func (q *FileQueue) Remove(id string) (*QueueItem, bool) {
+	item, ok := q.mem.Remove(id)
+	if !ok {
+		return nil, false
+	}
+
+	if err := q.Ack(item); err != nil {
+		log.Printf("FileQueue %s: failed to record removal of %s: %v", q.path, id, err)
+	}
+	return item, true
+}
+
+// RemoveOldest quita el item pendiente más antiguo y registra su descarte en el log
+func (q *FileQueue) RemoveOldest() (*QueueItem, bool) {
+	item, ok := q.mem.RemoveOldest()
+	if !ok {
+		return nil, false
+	}
+
+	if err := q.Ack(item); err != nil {
+		log.Printf("FileQueue %s: failed to record removal of %s: %v", q.path, item.ID, err)
+	}
+	return item, true
+}
+
+// Len devuelve el número de items pendientes en memoria
+func (q *FileQueue) Len() int {
+	return q.mem.Len()
+}
+
+// Close sincroniza y cierra el archivo de la cola
+func (q *FileQueue) Close() error {
+	q.mem.Close()
+
+	if q.stopSync != nil {
+		close(q.stopSync)
+		<-q.syncDone
+		q.stopSync = nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.closed {
+		return nil
+	}
+	q.closed = true
+
+	if err := q.file.Sync(); err != nil {
+		q.file.Close()
+		return fmt.Errorf("failed to sync queue file: %w", err)
+	}
+	return q.file.Close()
+}
+
+// append escribe un registro al final del log (requiere q.mu)
+func (q *FileQueue) append(record logRecord) error {
+	if q.closed {
+		return ErrQueueClosed
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode queue record: %w", err)
+	}
+
+	if _, err := q.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write queue record: %w", err)
+	}
+
+	if q.syncPolicy == SyncAlways {
+		if err := q.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync queue file: %w", err)
+		}
+	} else {
+		q.dirty = true
+	}
+
+	return nil
+}
+
+// compact reescribe el log solo con los items pendientes (requiere q.mu)
+func (q *FileQueue) compact() error {
+	tmpPath := q.path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to create compaction file: %w", err)
+	}
+
+	writer := bufio.NewWriter(tmp)
+	order := make([]string, 0, len(q.live))
+	for _, id := range q.order {
+		record, ok := q.live[id]
+		if !ok {
+			continue
+		}
+		line, err := json.Marshal(record)
+		if err != nil {
+			tmp.Close()
+			return fmt.Errorf("failed to encode queue record: %w", err)
+		}
+		writer.Write(append(line, '\n'))
+		order = append(order, id)
+	}
+
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write compaction file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync compaction file: %w", err)
+	}
+	tmp.Close()
+
+	if err := os.Rename(tmpPath, q.path); err != nil {
+		return fmt.Errorf("failed to replace queue file: %w", err)
+	}
+
+	file, err := os.OpenFile(q.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to reopen queue file: %w", err)
+	}
+
+	q.file.Close()
+	q.file = file
+	q.order = order
+	q.obsolete = 0
+	q.dirty = false
+
+	// El rename recién es durable cuando se sincroniza el directorio
+	if err := syncDir(q.path); err != nil {
+		return fmt.Errorf("failed to sync queue directory: %w", err)
+	}
+	return nil
+}
+
+// syncDir hace fsync del directorio que contiene path, para que sobreviva a
+// un corte el rename que lo reemplazó
+func syncDir(path string) error {
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+// syncLoop hace fsync periódico cuando la política es SyncInterval
+func (q *FileQueue) syncLoop() {
+	defer close(q.syncDone)
+
+	ticker := time.NewTicker(q.syncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			q.mu.Lock()
+			if q.dirty && !q.closed {
+				if err := q.file.Sync(); err != nil {
+					log.Printf("FileQueue %s: periodic sync failed: %v", q.path, err)
+				}
+				q.dirty = false
+			}
+			q.mu.Unlock()
+		case <-q.stopSync:
+			return
+		}
+	}
+}
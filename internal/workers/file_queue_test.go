@@ -0,0 +1,174 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// logLines cuenta los registros del log de la cola
+func logLines(t *testing.T, path string) int {
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	return bytes.Count(data, []byte("\n"))
+}
+
+// popNames extrae todos los items de la cola y devuelve sus nombres
+func popNames(t *testing.T, queue Queue) []string {
+	names := make([]string, 0)
+	for queue.Len() > 0 {
+		item, err := queue.Pop(context.Background())
+		assert.NoError(t, err)
+		names = append(names, item.Job.Name())
+	}
+	return names
+}
+
+func TestFileQueueRecover(t *testing.T) {
+	tests := []struct {
+		name    string
+		pushed  []string
+		acked   []string
+		popped  int
+		recover []string
+	}{
+		{"nothing acked", []string{"a", "b", "c"}, nil, 0, []string{"a", "b", "c"}},
+		{"acked are dropped", []string{"a", "b", "c"}, []string{"b"}, 0, []string{"a", "c"}},
+		{"popped without ack are retried", []string{"a", "b"}, nil, 2, []string{"a", "b"}},
+		{"all acked", []string{"a", "b"}, []string{"a", "b"}, 0, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "queue.log")
+			queue, err := NewFileQueue(path, 10, newTestRegistry(), WithFileQueueAging(0))
+			assert.NoError(t, err)
+
+			items := make(map[string]*QueueItem)
+			for _, name := range tt.pushed {
+				items[name] = newTestItem(name, 0)
+				assert.NoError(t, queue.Push(context.Background(), items[name]))
+			}
+			for _, name := range tt.acked {
+				_, removed := queue.Remove(items[name].ID)
+				assert.True(t, removed)
+			}
+			for range tt.popped {
+				_, err := queue.Pop(context.Background())
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, queue.Close())
+
+			reopened, err := NewFileQueue(path, 10, newTestRegistry(), WithFileQueueAging(0))
+			assert.NoError(t, err)
+			defer reopened.Close()
+
+			recovered, err := reopened.Recover()
+			assert.NoError(t, err)
+			assert.Len(t, recovered, len(tt.recover))
+			assert.Equal(t, tt.recover, popNames(t, reopened))
+		})
+	}
+}
+
+func TestFileQueueCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	queue, err := NewFileQueue(path, 0, newTestRegistry(), WithCompactThreshold(4))
+	assert.NoError(t, err)
+	defer queue.Close()
+
+	items := make([]*QueueItem, 0, 5)
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		item := newTestItem(name, 0)
+		assert.NoError(t, queue.Push(context.Background(), item))
+		items = append(items, item)
+	}
+	assert.Equal(t, 5, logLines(t, path))
+
+	// Dos acks dejan 4 registros obsoletos y 3 vivos: se reescribe el log
+	for _, item := range items[:2] {
+		popped, err := queue.Pop(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, queue.Ack(popped))
+		assert.Equal(t, item.ID, popped.ID)
+	}
+	assert.Equal(t, 3, logLines(t, path))
+}
+
+func TestFileQueueRejectedPushIsNotPersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	queue, err := NewFileQueue(path, 1, newTestRegistry())
+	assert.NoError(t, err)
+	defer queue.Close()
+
+	assert.NoError(t, queue.Push(context.Background(), newTestItem("a", 0)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, queue.Push(ctx, newTestItem("b", 0)), context.Canceled)
+
+	assert.Equal(t, 1, logLines(t, path))
+	assert.Equal(t, 1, queue.Len())
+}
+
+func TestFileQueueRejectedSubmitsDoNotReappear(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	registry := newTestRegistry()
+	queue, err := NewFileQueue(path, 2, registry)
+	assert.NoError(t, err)
+
+	pool := NewWorkerPool(1, 2, WithQueue(queue))
+	assert.NoError(t, pool.Start())
+	pool.Pause()
+
+	rejected := 0
+	for range 10 {
+		if err := pool.TrySubmit(&serialJob{testJob: newTestJob("job", nil)}); err != nil {
+			rejected++
+		}
+	}
+	assert.Equal(t, 8, rejected)
+	assert.Equal(t, 2, logLines(t, path))
+	pool.Stop()
+
+	reopened, err := NewFileQueue(path, 2, registry)
+	assert.NoError(t, err)
+	defer reopened.Close()
+	recovered, err := reopened.Recover()
+	assert.NoError(t, err)
+	assert.Len(t, recovered, 2)
+}
+
+func TestFileQueueNonSerializableJob(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []FileQueueOption
+		wantErr bool
+		wantLen int
+	}{
+		{"rejected by default", nil, true, 0},
+		{"kept in memory with fallback", []FileQueueOption{WithMemoryFallback()}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "queue.log")
+			queue, err := NewFileQueue(path, 10, newTestRegistry(), tt.opts...)
+			assert.NoError(t, err)
+			defer queue.Close()
+
+			err = queue.Push(context.Background(), newQueueItem(newTestJob("plain", nil)))
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantLen, queue.Len())
+			assert.Equal(t, 0, logLines(t, path))
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// TypedJob es un job que produce un valor de tipo T. La forma más simple de
+// implementarlo es embeber types.JobOutput[T] y llamar a SetOutput desde Execute.
+type TypedJob[T any] interface {
+	types.OutputJob
+	SetOutput(value T)
+}
+
+// FuncJob adapta una función que devuelve un valor a un TypedJob
+type FuncJob[T any] struct {
+	types.JobOutput[T]
+	JobName     string
+	JobPriority int
+	Fn          func(ctx context.Context) (T, error)
+}
+
+// Execute implementa la interfaz Job
+func (j *FuncJob[T]) Execute(ctx context.Context) error {
+	value, err := j.Fn(ctx)
+	if err != nil {
+		return err
+	}
+	j.SetOutput(value)
+	return nil
+}
+
+// Name implementa la interfaz Job
+func (j *FuncJob[T]) Name() string {
+	return j.JobName
+}
+
+// Priority implementa la interfaz Job
+func (j *FuncJob[T]) Priority() int {
+	return j.JobPriority
+}
+
+// Future es el valor que producirá un job encolado
+type Future[T any] struct {
+	handle *JobHandle
+}
+
+// SubmitJob encola un job tipado y devuelve un Future con su valor. Como
+// Dispatcher.Submit, no propaga valores de contexto.
+func SubmitJob[T any](d *Dispatcher, job TypedJob[T]) (*Future[T], error) {
+	handle, err := d.Submit(job)
+	if err != nil {
+		return nil, err
+	}
+	return &Future[T]{handle: handle}, nil
+}
+
+// SubmitFunc encola fn como un job con el nombre indicado y devuelve un Future
+// con el valor que devuelva
+func SubmitFunc[T any](d *Dispatcher, name string, fn func(ctx context.Context) (T, error)) (*Future[T], error) {
+	return SubmitJob(d, &FuncJob[T]{JobName: name, Fn: fn})
+}
+
+// ID devuelve el identificador con el que se rastrea el job
+func (f *Future[T]) ID() string {
+	return f.handle.ID()
+}
+
+// Handle devuelve el handle del job para consultarlo o cancelarlo
+func (f *Future[T]) Handle() *JobHandle {
+	return f.handle
+}
+
+// Done devuelve un canal que se cierra cuando el job termina
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.handle.Done()
+}
+
+// Await espera a que el job termine y devuelve su valor, o el error del job
+// si falló o el de ctx si vence antes
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	var zero T
+
+	result, err := f.handle.Wait(ctx)
+	if err != nil {
+		return zero, err
+	}
+	if !result.Success {
+		return zero, result.Error
+	}
+	if result.Data == nil {
+		return zero, nil
+	}
+
+	value, ok := result.Data.(T)
+	if !ok {
+		// Puede pasar si se unió por idempotencia a un job de otro tipo
+		return zero, fmt.Errorf("job %s produced %T, expected %T", result.JobName, result.Data, zero)
+	}
+	return value, nil
+}
+
+// AwaitAll espera a todos los futures y devuelve sus valores en el mismo
+// orden. Los que fallaron quedan en cero y sus errores se devuelven juntos.
+// Si ctx vence antes devuelve su error.
+func AwaitAll[T any](ctx context.Context, futures ...*Future[T]) ([]T, error) {
+	values := make([]T, len(futures))
+	var errs []error
+
+	for i, future := range futures {
+		value, err := future.Await(ctx)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return values, ctxErr
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("job %s: %w", future.ID(), err))
+			continue
+		}
+		values[i] = value
+	}
+	return values, errors.Join(errs...)
+}
+
+// AwaitAny devuelve el índice y el valor del primer future que termine con
+// éxito. Si fallan todos devuelve sus errores juntos, y si ctx vence antes
+// devuelve su error.
+func AwaitAny[T any](ctx context.Context, futures ...*Future[T]) (int, T, error) {
+	var zero T
+	if len(futures) == 0 {
+		return -1, zero, errors.New("no futures to await")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	finished := make(chan int, len(futures))
+	for i, future := range futures {
+		go func() {
+			select {
+			case <-future.Done():
+				finished <- i
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	errs := make([]error, 0, len(futures))
+	for range futures {
+		select {
+		case i := <-finished:
+			value, err := futures[i].Await(ctx)
+			if err == nil {
+				return i, value, nil
+			}
+			errs = append(errs, fmt.Errorf("job %s: %w", futures[i].ID(), err))
+		case <-ctx.Done():
+			return -1, zero, ctx.Err()
+		}
+	}
+	return -1, zero, errors.Join(errs...)
+}
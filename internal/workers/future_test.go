@@ -0,0 +1,149 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newFutureDispatcher crea un dispatcher con workers suficientes para que los
+// futures de cada test corran en paralelo
+func newFutureDispatcher(t *testing.T) *Dispatcher {
+	d := NewDispatcher(context.Background(), 4, 10)
+	assert.NoError(t, d.Start())
+	t.Cleanup(d.Stop)
+	return d
+}
+
+// submitValue encola un job que devuelve value (o err) tras esperar delay
+func submitValue[T any](t *testing.T, d *Dispatcher, name string, delay time.Duration, value T, err error) *Future[T] {
+	t.Helper()
+	future, submitErr := SubmitFunc(d, name, func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		}
+		return value, err
+	})
+	assert.NoError(t, submitErr)
+	return future
+}
+
+type quote struct {
+	OfferID string
+	Price   float64
+}
+
+func TestFutureAwaitTypedValue(t *testing.T) {
+	d := newFutureDispatcher(t)
+
+	future := submitValue(t, d, "quote", 0, quote{OfferID: "offer-1", Price: 9.5}, nil)
+	value, err := future.Await(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, quote{OfferID: "offer-1", Price: 9.5}, value)
+	assert.Equal(t, JobSucceeded, future.Handle().Status().Status)
+}
+
+func TestFutureAwaitJobError(t *testing.T) {
+	d := newFutureDispatcher(t)
+	errNotFound := errors.New("offer not found")
+
+	future := submitValue(t, d, "quote", 0, 0, errNotFound)
+	value, err := future.Await(context.Background())
+	assert.ErrorIs(t, err, errNotFound)
+	assert.Zero(t, value)
+}
+
+func TestFutureAwaitContextCancelled(t *testing.T) {
+	d := newFutureDispatcher(t)
+
+	future := submitValue(t, d, "slow", time.Minute, 1, nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	value, err := future.Await(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Zero(t, value)
+	// Dejar de esperar no cancela el job
+	assert.Equal(t, JobRunning, future.Handle().Status().Status)
+}
+
+func TestAwaitAll(t *testing.T) {
+	d := newFutureDispatcher(t)
+	errBoom := errors.New("boom")
+
+	t.Run("values in order", func(t *testing.T) {
+		values, err := AwaitAll(context.Background(),
+			submitValue(t, d, "a", 30*time.Millisecond, "a", nil),
+			submitValue(t, d, "b", 0, "b", nil),
+			submitValue(t, d, "c", 10*time.Millisecond, "c", nil),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"a", "b", "c"}, values)
+	})
+
+	t.Run("failed futures stay zero", func(t *testing.T) {
+		failed := submitValue(t, d, "b", 0, "b", errBoom)
+		values, err := AwaitAll(context.Background(),
+			submitValue(t, d, "a", 0, "a", nil),
+			failed,
+			submitValue(t, d, "c", 0, "c", nil),
+		)
+		assert.ErrorIs(t, err, errBoom)
+		assert.ErrorContains(t, err, failed.ID())
+		assert.Equal(t, []string{"a", "", "c"}, values)
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err := AwaitAll(ctx,
+			submitValue(t, d, "a", 0, "a", nil),
+			submitValue(t, d, "slow", time.Minute, "slow", nil),
+		)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
+
+func TestAwaitAny(t *testing.T) {
+	d := newFutureDispatcher(t)
+	errBoom := errors.New("boom")
+
+	t.Run("first success wins", func(t *testing.T) {
+		index, value, err := AwaitAny(context.Background(),
+			submitValue(t, d, "slow", time.Minute, "slow", nil),
+			submitValue(t, d, "failed", 0, "", errBoom),
+			submitValue(t, d, "fast", 20*time.Millisecond, "fast", nil),
+		)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, index)
+		assert.Equal(t, "fast", value)
+	})
+
+	t.Run("all failed", func(t *testing.T) {
+		index, _, err := AwaitAny(context.Background(),
+			submitValue(t, d, "a", 0, "", errBoom),
+			submitValue(t, d, "b", 10*time.Millisecond, "", errBoom),
+		)
+		assert.Equal(t, -1, index)
+		assert.ErrorIs(t, err, errBoom)
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		index, _, err := AwaitAny(ctx, submitValue(t, d, "slow", time.Minute, "slow", nil))
+		assert.Equal(t, -1, index)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("no futures", func(t *testing.T) {
+		_, _, err := AwaitAny[string](context.Background())
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// testJob es un Job configurable para los tests
+type testJob struct {
+	name     string
+	priority int
+	run      func(ctx context.Context) error
+}
+
+func newTestJob(name string, run func(ctx context.Context) error) *testJob {
+	return &testJob{name: name, run: run}
+}
+
+func (j *testJob) Execute(ctx context.Context) error {
+	if j.run == nil {
+		return nil
+	}
+	return j.run(ctx)
+}
+
+func (j *testJob) Name() string  { return j.name }
+func (j *testJob) Priority() int { return j.priority }
+
+// retryJob es un testJob con su propia política de reintentos
+type retryJob struct {
+	*testJob
+	policy types.RetryPolicy
+}
+
+func (j *retryJob) RetryPolicy() types.RetryPolicy { return j.policy }
+
+// testJobType es el tipo con el que se registra serialJob
+const testJobType = "test"
+
+// serialJob es un testJob que se puede persistir en una FileQueue, con una
+// clave de idempotencia opcional
+type serialJob struct {
+	*testJob
+	key string
+}
+
+func (j *serialJob) JobType() string        { return testJobType }
+func (j *serialJob) IdempotencyKey() string { return j.key }
+
+func (j *serialJob) Marshal() ([]byte, error) {
+	return json.Marshal([]string{j.name, j.key})
+}
+
+// newTestRegistry crea un registro que reconstruye serialJobs
+func newTestRegistry() *JobRegistry {
+	registry := NewJobRegistry()
+	registry.Register(testJobType, func(payload []byte) (types.Job, error) {
+		var fields []string
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+		return &serialJob{testJob: newTestJob(fields[0], nil), key: fields[1]}, nil
+	})
+	return registry
+}
+
+// newTestItem crea un item de un serialJob con la prioridad indicada
+func newTestItem(name string, priority int) *QueueItem {
+	job := &serialJob{testJob: newTestJob(name, nil)}
+	job.priority = priority
+	return newQueueItem(job)
+}
+
+// responseJob es un testJob que reporta su resultado por un canal
+type responseJob struct {
+	*testJob
+	id       string
+	response chan<- types.JobResult
+	timeout  time.Duration
+}
+
+func (j *responseJob) ID() string                              { return j.id }
+func (j *responseJob) ResponseChannel() chan<- types.JobResult { return j.response }
+func (j *responseJob) Timeout() time.Duration                  { return j.timeout }
@@ -0,0 +1,410 @@
+package worker
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// DefaultHistoryCapacity es la cantidad de resultados que retiene el historial en memoria por defecto
+const DefaultHistoryCapacity = 10000
+
+// historyBufferSize es la cantidad de resultados que el dispatcher puede tener
+// pendientes de guardar en el historial antes de que los workers esperen
+const historyBufferSize = 1000
+
+// HistoryEntry es el resultado final de un job guardado en el historial
+type HistoryEntry struct {
+	ID         string        `json:"id"`
+	JobName    string        `json:"job_name"`
+	Queue      string        `json:"queue"`
+	Status     JobStatus     `json:"status"`
+	Priority   int           `json:"priority"`
+	RequestID  string        `json:"request_id,omitempty"`
+	Attempts   int           `json:"attempts"`
+	Error      string        `json:"error,omitempty"`
+	EnqueuedAt time.Time     `json:"enqueued_at"`
+	FinishedAt time.Time     `json:"finished_at"`
+	Duration   time.Duration `json:"duration"`
+}
+
+// HistoryQuery filtra las consultas al historial. Los campos vacíos no filtran.
+type HistoryQuery struct {
+	// ID es el ID del job
+	ID string
+	// JobName es un prefijo del nombre del job
+	JobName string
+	// Status es el estado final del job
+	Status JobStatus
+	// Since y Until acotan FinishedAt al intervalo [Since, Until)
+	Since time.Time
+	Until time.Time
+	// Limit es la cantidad máxima de resultados (0 = sin límite)
+	Limit int
+}
+
+// Matches indica si la entrada cumple los filtros de la consulta
+func (q HistoryQuery) Matches(entry HistoryEntry) bool {
+	return (q.ID == "" || entry.ID == q.ID) &&
+		strings.HasPrefix(entry.JobName, q.JobName) &&
+		(q.Status == "" || entry.Status == q.Status) &&
+		(q.Since.IsZero() || !entry.FinishedAt.Before(q.Since)) &&
+		(q.Until.IsZero() || entry.FinishedAt.Before(q.Until))
+}
+
+// HistoryStore guarda los resultados finales de los jobs para consultarlos
+// después de que el pool los olvida
+type HistoryStore interface {
+	// Add guarda el resultado de un job. Un job recuperado de una cola durable
+	// puede terminar más de una vez con el mismo ID.
+	Add(entry HistoryEntry) error
+	// Query devuelve las entradas que cumplen la consulta, las más recientes primero
+	Query(query HistoryQuery) ([]HistoryEntry, error)
+	// Prune elimina las entradas que terminaron antes de before y devuelve cuántas eliminó
+	Prune(before time.Time) (int, error)
+}
+
+// WithHistoryStore reemplaza el historial en memoria donde se guardan los
+// resultados de los jobs (por ejemplo por un FileHistoryStore o un SQLHistoryStore)
+func WithHistoryStore(store HistoryStore) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.history = store
+	}
+}
+
+// WithHistoryRetention elimina periódicamente del historial los resultados
+// con más antigüedad que maxAge
+func WithHistoryRetention(maxAge time.Duration) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.historyRetention = maxAge
+	}
+}
+
+// withHistoryStore define dónde se guardan los resultados de los jobs
+func withHistoryStore(store HistoryStore) PoolOption {
+	return func(c *poolConfig) {
+		c.history = store
+	}
+}
+
+// resultStatus devuelve el estado final que corresponde al resultado
+func resultStatus(result types.JobResult) JobStatus {
+	switch {
+	case result.Success:
+		return JobSucceeded
+	case errors.Is(result.Error, ErrJobCancelled):
+		return JobCancelled
+	default:
+		return JobFailed
+	}
+}
+
+// recordHistory guarda en el historial el resultado final del item
+func (p *Pool) recordHistory(item *QueueItem, result types.JobResult) {
+	if p.history == nil {
+		return
+	}
+
+	entry := HistoryEntry{
+		ID:         item.ID,
+		JobName:    item.Job.Name(),
+		Queue:      p.name,
+		Status:     resultStatus(result),
+		Priority:   item.Priority,
+		RequestID:  item.RequestID,
+		Attempts:   result.Attempts,
+		EnqueuedAt: item.EnqueuedAt,
+		FinishedAt: result.Timestamp,
+		Duration:   result.Duration,
+	}
+	if result.Error != nil {
+		entry.Error = result.Error.Error()
+	}
+
+	if err := p.history.Add(entry); err != nil {
+		log.Printf("Worker pool: failed to record history of job %s: %v", entry.JobName, err)
+	}
+}
+
+// historyWrite es una entrada a guardar o, si synced no es nil, un aviso de
+// que ya se guardó todo lo encolado antes
+type historyWrite struct {
+	entry  HistoryEntry
+	synced chan struct{}
+}
+
+// historyWriter guarda en segundo plano los resultados en el historial del
+// dispatcher, así un store lento (por ejemplo una base de datos) no demora a
+// los workers. Con el buffer lleno Add espera lugar en lugar de descartar.
+// Antes de start y después de stop guarda directamente en el store.
+type historyWriter struct {
+	store HistoryStore
+
+	mu     sync.RWMutex
+	writes chan historyWrite
+	done   chan struct{}
+}
+
+// newHistoryWriter crea un writer sobre el store indicado
+func newHistoryWriter(store HistoryStore) *historyWriter {
+	return &historyWriter{store: store}
+}
+
+// start lanza la goroutine que guarda las entradas encoladas
+func (w *historyWriter) start() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.writes != nil {
+		return
+	}
+	writes := make(chan historyWrite, historyBufferSize)
+	done := make(chan struct{})
+	w.writes, w.done = writes, done
+
+	go func() {
+		defer close(done)
+		for write := range writes {
+			if write.synced != nil {
+				close(write.synced)
+				continue
+			}
+			if err := w.store.Add(write.entry); err != nil {
+				log.Printf("Failed to record history of job %s: %v", write.entry.JobName, err)
+			}
+		}
+	}()
+}
+
+// stop espera a que se guarden las entradas pendientes y detiene la goroutine
+func (w *historyWriter) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.writes == nil {
+		return
+	}
+	close(w.writes)
+	<-w.done
+	w.writes = nil
+}
+
+// sync espera a que se guarden las entradas encoladas hasta ahora, así una
+// consulta ve los resultados de los jobs que ya terminaron
+func (w *historyWriter) sync() {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.writes == nil {
+		return
+	}
+	synced := make(chan struct{})
+	w.writes <- historyWrite{synced: synced}
+	<-synced
+}
+
+// Add implementa HistoryStore encolando la entrada
+func (w *historyWriter) Add(entry HistoryEntry) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.writes == nil {
+		return w.store.Add(entry)
+	}
+	w.writes <- historyWrite{entry: entry}
+	return nil
+}
+
+// Query implementa HistoryStore
+func (w *historyWriter) Query(query HistoryQuery) ([]HistoryEntry, error) {
+	w.sync()
+	return w.store.Query(query)
+}
+
+// Prune implementa HistoryStore
+func (w *historyWriter) Prune(before time.Time) (int, error) {
+	w.sync()
+	return w.store.Prune(before)
+}
+
+// MemoryHistoryStore es un HistoryStore en memoria con capacidad acotada.
+// Al llenarse reemplaza la entrada más antigua.
+type MemoryHistoryStore struct {
+	mu      sync.RWMutex
+	entries []HistoryEntry
+	// start es la posición de la entrada más antigua una vez lleno; las
+	// entradas se usan como buffer circular para no desplazarlas en cada Add
+	start    int
+	capacity int
+}
+
+// NewMemoryHistoryStore crea un historial en memoria (capacity <= 0 = sin límite)
+func NewMemoryHistoryStore(capacity int) *MemoryHistoryStore {
+	return &MemoryHistoryStore{
+		entries:  make([]HistoryEntry, 0),
+		capacity: capacity,
+	}
+}
+
+// Add implementa HistoryStore
+func (s *MemoryHistoryStore) Add(entry HistoryEntry) error {
+	if entry.ID == "" {
+		return fmt.Errorf("history entry requires an ID")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.capacity <= 0 || len(s.entries) < s.capacity {
+		s.entries = append(s.entries, entry)
+		return nil
+	}
+	s.entries[s.start] = entry
+	s.start = (s.start + 1) % len(s.entries)
+	return nil
+}
+
+// at devuelve la i-ésima entrada en orden de llegada (requiere s.mu)
+func (s *MemoryHistoryStore) at(i int) HistoryEntry {
+	return s.entries[(s.start+i)%len(s.entries)]
+}
+
+// Query implementa HistoryStore
+func (s *MemoryHistoryStore) Query(query HistoryQuery) ([]HistoryEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]HistoryEntry, 0)
+	for i := len(s.entries) - 1; i >= 0; i-- {
+		if entry := s.at(i); query.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	// Se agregan al terminar, así que casi siempre ya están ordenadas
+	slices.SortStableFunc(entries, func(a, b HistoryEntry) int {
+		return b.FinishedAt.Compare(a.FinishedAt)
+	})
+	if query.Limit > 0 && len(entries) > query.Limit {
+		entries = entries[:query.Limit]
+	}
+	return entries, nil
+}
+
+// Prune implementa HistoryStore
+func (s *MemoryHistoryStore) Prune(before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	kept := make([]HistoryEntry, 0, len(s.entries))
+	for i := range s.entries {
+		if entry := s.at(i); !entry.FinishedAt.Before(before) {
+			kept = append(kept, entry)
+		}
+	}
+
+	pruned := len(s.entries) - len(kept)
+	s.entries = kept
+	s.start = 0
+	return pruned, nil
+}
+
+// Len devuelve la cantidad de entradas almacenadas
+func (s *MemoryHistoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries)
+}
+
+// History devuelve los resultados de los jobs que cumplen la consulta, los
+// más recientes primero
+func (d *Dispatcher) History(query HistoryQuery) ([]HistoryEntry, error) {
+	return d.history.Query(query)
+}
+
+// HistoryEntry devuelve el último resultado del job con el ID indicado
+func (d *Dispatcher) HistoryEntry(id string) (HistoryEntry, error) {
+	entries, err := d.history.Query(HistoryQuery{ID: id, Limit: 1})
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	if len(entries) == 0 {
+		return HistoryEntry{}, fmt.Errorf("job %s not found in history", id)
+	}
+	return entries[0], nil
+}
+
+// PruneHistory elimina del historial los resultados anteriores a before
+func (d *Dispatcher) PruneHistory(before time.Time) (int, error) {
+	return d.history.Prune(before)
+}
+
+// pruneHistory aplica la retención configurada al historial
+func (d *Dispatcher) pruneHistory() {
+	if d.retention <= 0 {
+		return
+	}
+
+	pruned, err := d.history.Prune(time.Now().Add(-d.retention))
+	if err != nil {
+		log.Printf("Failed to prune job history: %v", err)
+		return
+	}
+	if pruned > 0 {
+		log.Printf("Pruned %d job history entries older than %v", pruned, d.retention)
+	}
+}
+
+// historyCSVHeader son las columnas de ExportHistoryCSV
+var historyCSVHeader = []string{
+	"id", "job_name", "queue", "status", "priority", "request_id",
+	"attempts", "error", "enqueued_at", "finished_at", "duration_ms",
+}
+
+// ExportHistoryCSV escribe las entradas como CSV, con una fila de encabezado
+func ExportHistoryCSV(w io.Writer, entries []HistoryEntry) error {
+	writer := csv.NewWriter(w)
+	writer.Write(historyCSVHeader)
+	for _, entry := range entries {
+		writer.Write([]string{
+			entry.ID,
+			entry.JobName,
+			entry.Queue,
+			string(entry.Status),
+			strconv.Itoa(entry.Priority),
+			entry.RequestID,
+			strconv.Itoa(entry.Attempts),
+			entry.Error,
+			entry.EnqueuedAt.Format(time.RFC3339Nano),
+			entry.FinishedAt.Format(time.RFC3339Nano),
+			strconv.FormatFloat(float64(entry.Duration)/float64(time.Millisecond), 'f', 2, 64),
+		})
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	return nil
+}
+
+// ExportHistoryJSON escribe las entradas como un array JSON
+func ExportHistoryJSON(w io.Writer, entries []HistoryEntry) error {
+	if entries == nil {
+		entries = []HistoryEntry{}
+	}
+	if err := json.NewEncoder(w).Encode(entries); err != nil {
+		return fmt.Errorf("failed to export history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func historyIDs(entries []HistoryEntry) []string {
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		ids = append(ids, entry.ID)
+	}
+	return ids
+}
+
+func TestMemoryHistoryStoreKeepsNewestEntries(t *testing.T) {
+	start := time.Now()
+	store := NewMemoryHistoryStore(3)
+	for i := range 5 {
+		entry := HistoryEntry{ID: fmt.Sprintf("job-%d", i), FinishedAt: start.Add(time.Duration(i) * time.Second)}
+		assert.NoError(t, store.Add(entry))
+	}
+
+	entries, err := store.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-4", "job-3", "job-2"}, historyIDs(entries))
+	assert.Equal(t, 3, store.Len())
+
+	pruned, err := store.Prune(start.Add(4 * time.Second))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, pruned)
+
+	assert.NoError(t, store.Add(HistoryEntry{ID: "job-5", FinishedAt: start.Add(5 * time.Second)}))
+	entries, err = store.Query(HistoryQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"job-5"}, historyIDs(entries))
+	assert.Equal(t, 2, store.Len())
+}
+
+// slowHistoryStore es un historial en memoria que demora cada Add
+type slowHistoryStore struct {
+	*MemoryHistoryStore
+	delay time.Duration
+}
+
+func (s *slowHistoryStore) Add(entry HistoryEntry) error {
+	time.Sleep(s.delay)
+	return s.MemoryHistoryStore.Add(entry)
+}
+
+func TestHistoryWriterDoesNotWaitForStore(t *testing.T) {
+	store := &slowHistoryStore{MemoryHistoryStore: NewMemoryHistoryStore(0), delay: 20 * time.Millisecond}
+	writer := newHistoryWriter(store)
+	writer.start()
+	defer writer.stop()
+
+	start := time.Now()
+	for i := range 5 {
+		assert.NoError(t, writer.Add(HistoryEntry{ID: fmt.Sprintf("job-%d", i), FinishedAt: time.Now()}))
+	}
+	assert.Less(t, time.Since(start), 20*time.Millisecond)
+
+	// Las consultas ven lo que ya se agregó
+	entries, err := writer.Query(HistoryQuery{})
+	assert.NoError(t, err)
+	assert.Len(t, entries, 5)
+}
+
+func TestStopRecordsAbandonedJobs(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+
+	running := make(chan struct{})
+	assert.NoError(t, d.EnqueueJob(newTestJob("running", func(ctx context.Context) error {
+		close(running)
+		<-ctx.Done()
+		return ctx.Err()
+	})))
+	assert.NoError(t, d.EnqueueJob(newTestJob("queued", nil)))
+	<-running
+
+	d.Stop()
+
+	entries, err := d.History(HistoryQuery{})
+	assert.NoError(t, err)
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.JobName)
+		assert.Equal(t, JobFailed, entry.Status)
+		assert.Equal(t, ErrJobAbandoned.Error(), entry.Error)
+	}
+	assert.ElementsMatch(t, []string{"running", "queued"}, names)
+}
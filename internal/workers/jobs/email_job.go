@@ -2,19 +2,23 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"time"
+
+	"clean-arq-layout/internal/workers/types"
 )
 
-// EmailJob represents an email sending job with retry logic
+// EmailJobType identifies EmailJob in the job registry
+const EmailJobType = "email"
+
+// EmailJob represents an email sending job with retry policy
 type EmailJob struct {
-	ID        string
-	Recipient string
-	Subject   string
-	Body      string
-	MaxRetries int
-	currentRetry int
+	ID         string `json:"id"`
+	Recipient  string `json:"recipient"`
+	Subject    string `json:"subject"`
+	Body       string `json:"body"`
+	MaxRetries int    `json:"max_retries"`
 }
 
 // NewEmailJob creates a new email job
@@ -28,47 +32,18 @@ func NewEmailJob(id, recipient, subject, body string) *EmailJob {
 	}
 }
 
-// Execute implements Job interface with retry logic
+// Execute implements Job interface. Retries are handled by the worker
+// according to RetryPolicy.
 func (j *EmailJob) Execute(ctx context.Context) error {
-	log.Printf("Starting email job %s to %s", j.ID, j.Recipient)
-
-	for j.currentRetry <= j.MaxRetries {
-		select {
-		case <-ctx.Done():
-			log.Printf("Email job %s was canceled: %v", j.ID, ctx.Err())
-			return ctx.Err()
-		default:
-		}
-
-		// Simulate email sending (with potential failure)
-		if err := j.sendEmail(ctx); err != nil {
-			j.currentRetry++
-			if j.currentRetry <= j.MaxRetries {
-				log.Printf("Email job %s failed (attempt %d/%d): %v, retrying...", 
-					j.ID, j.currentRetry, j.MaxRetries, err)
-				
-				// Wait before retry with exponential backoff
-				backoff := time.Duration(j.currentRetry*j.currentRetry) * time.Second
-				timer := time.NewTimer(backoff)
-				
-				select {
-				case <-timer.C:
-					continue
-				case <-ctx.Done():
-					timer.Stop()
-					return ctx.Err()
-				}
-			} else {
-				log.Printf("Email job %s failed permanently after %d attempts", j.ID, j.MaxRetries)
-				return fmt.Errorf("email job failed after %d attempts: %w", j.MaxRetries, err)
-			}
-		} else {
-			log.Printf("Email job %s completed successfully", j.ID)
-			return nil
-		}
+	if err := j.sendEmail(ctx); err != nil {
+		return fmt.Errorf("email job %s failed: %w", j.ID, err)
 	}
+	return nil
+}
 
-	return fmt.Errorf("email job %s exhausted all retry attempts", j.ID)
+// RetryPolicy implements RetryableJob: exponential backoff with jitter
+func (j *EmailJob) RetryPolicy() types.RetryPolicy {
+	return types.ExponentialRetry(j.MaxRetries+1, time.Second)
 }
 
 // sendEmail simulates sending an email
@@ -94,4 +69,23 @@ func (j *EmailJob) Name() string {
 // Priority returns priority (higher number = higher priority)
 func (j *EmailJob) Priority() int {
 	return 2 // Higher priority than simple jobs
-}
\ No newline at end of file
+}
+
+// JobType implements SerializableJob
+func (j *EmailJob) JobType() string {
+	return EmailJobType
+}
+
+// Marshal implements SerializableJob
+func (j *EmailJob) Marshal() ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// DecodeEmailJob rebuilds an EmailJob from its serialized payload
+func DecodeEmailJob(payload []byte) (types.Job, error) {
+	job := &EmailJob{}
+	if err := json.Unmarshal(payload, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
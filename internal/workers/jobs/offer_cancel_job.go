@@ -2,6 +2,7 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"time"
 
@@ -9,6 +10,19 @@ import (
 	"clean-arq-layout/internal/workers/types"
 )
 
+// PriceServiceResource es la clave de recurso para limitar la tasa de llamadas al servicio de precios
+const PriceServiceResource = "price-service"
+
+// OfferCancelJobType identifica a OfferCancelJob en el registro de jobs
+const OfferCancelJobType = "offer-cancel"
+
+// offerCancelPayload son los datos persistidos de un OfferCancelJob
+type offerCancelPayload struct {
+	ID         string `json:"id"`
+	OfferID    string `json:"offer_id"`
+	MaxRetries int    `json:"max_retries"`
+}
+
 // OfferCancelJob job para cancelar ofertas usando un cliente de servicio inyectado
 type OfferCancelJob struct {
 	id              string
@@ -16,10 +30,10 @@ type OfferCancelJob struct {
 	priceService    interfaces.PriceServiceClient
 	responseChannel chan<- types.JobResult
 	maxRetries      int
-	currentRetry    int
 }
 
-// NewOfferCancelJob crea un nuevo job de cancelación de oferta
+// NewOfferCancelJob crea un nuevo job de cancelación de oferta. responseChannel
+// puede ser nil si el resultado se sigue con el JobHandle de Submit.
 func NewOfferCancelJob(id, offerID string, priceService interfaces.PriceServiceClient, responseChannel chan<- types.JobResult) *OfferCancelJob {
 	return &OfferCancelJob{
 		id:              id,
@@ -30,41 +44,42 @@ func NewOfferCancelJob(id, offerID string, priceService interfaces.PriceServiceC
 	}
 }
 
-// Execute implementa la interfaz Job
+// Execute implementa la interfaz Job. Los reintentos los aplica el worker
+// según RetryPolicy.
 func (j *OfferCancelJob) Execute(ctx context.Context) error {
-	for j.currentRetry <= j.maxRetries {
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		default:
-		}
-
-		// Llamar al méthodo Cancel del cliente de servicio
-		err := j.priceService.Cancel(ctx, j.offerID)
-		if err != nil {
-			j.currentRetry++
-			if j.currentRetry <= j.maxRetries {
-				// Backoff exponencial
-				backoff := time.Duration(j.currentRetry*j.currentRetry) * time.Second
-				timer := time.NewTimer(backoff)
-
-				select {
-				case <-timer.C:
-					continue
-				case <-ctx.Done():
-					timer.Stop()
-					return ctx.Err()
-				}
-			} else {
-				return fmt.Errorf("offer cancellation failed after %d attempts: %w", j.maxRetries, err)
-			}
-		} else {
-			// Éxito
-			return nil
-		}
+	// Llamar al méthodo Cancel del cliente de servicio
+	if err := j.priceService.Cancel(ctx, j.offerID); err != nil {
+		return fmt.Errorf("offer cancellation failed for offer %s: %w", j.offerID, err)
 	}
+	return nil
+}
+
+// RetryPolicy implementa la interfaz RetryableJob con backoff exponencial
+func (j *OfferCancelJob) RetryPolicy() types.RetryPolicy {
+	return types.ExponentialRetry(j.maxRetries+1, time.Second)
+}
 
-	return fmt.Errorf("offer cancellation exhausted all retry attempts for offer %s", j.offerID)
+// Timeout implementa la interfaz TimeoutJob: alcanza para todos los reintentos
+// de una llamada HTTP, muy por debajo del timeout por defecto del pool
+func (j *OfferCancelJob) Timeout() time.Duration {
+	return 2 * time.Minute
+}
+
+// ResourceKey implementa la interfaz ResourceJob
+func (j *OfferCancelJob) ResourceKey() string {
+	return PriceServiceResource
+}
+
+// IdempotencyKey implementa la interfaz IdempotentJob: una misma oferta no se
+// cancela dos veces en paralelo
+func (j *OfferCancelJob) IdempotencyKey() string {
+	return fmt.Sprintf("offer-cancel:%s", j.offerID)
+}
+
+// OrderingKey implementa la interfaz OrderedJob: los jobs sobre la misma
+// oferta (actualización de precio, cancelación) se ejecutan en orden
+func (j *OfferCancelJob) OrderingKey() string {
+	return fmt.Sprintf("offer:%s", j.offerID)
 }
 
 // Name implementa la interfaz Job
@@ -96,3 +111,33 @@ func (j *OfferCancelJob) GetOfferID() string {
 func (j *OfferCancelJob) SetMaxRetries(maxRetries int) {
 	j.maxRetries = maxRetries
 }
+
+// JobType implementa la interfaz SerializableJob
+func (j *OfferCancelJob) JobType() string {
+	return OfferCancelJobType
+}
+
+// Marshal implementa la interfaz SerializableJob. Las dependencias (cliente y
+// canal de respuesta) no se persisten: las inyecta el decoder al recuperar.
+func (j *OfferCancelJob) Marshal() ([]byte, error) {
+	return json.Marshal(offerCancelPayload{
+		ID:         j.id,
+		OfferID:    j.offerID,
+		MaxRetries: j.maxRetries,
+	})
+}
+
+// NewOfferCancelJobDecoder crea un decoder que reconstruye jobs de cancelación
+// inyectando el cliente de servicio y el canal de respuesta indicados
+func NewOfferCancelJobDecoder(priceService interfaces.PriceServiceClient, responseChannel chan<- types.JobResult) func([]byte) (types.Job, error) {
+	return func(payload []byte) (types.Job, error) {
+		var data offerCancelPayload
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+
+		job := NewOfferCancelJob(data.ID, data.OfferID, priceService, responseChannel)
+		job.SetMaxRetries(data.MaxRetries)
+		return job, nil
+	}
+}
@@ -2,16 +2,21 @@ package jobs
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
-	"log"
 	"time"
+
+	"clean-arq-layout/internal/workers/types"
 )
 
+// SimpleJobType identifica a SimpleJob en el registro de jobs
+const SimpleJobType = "simple"
+
 // TIP SimpleJob is a basic job for processing without dependencies
 type SimpleJob struct {
-	ID    string
-	Data  string
-	Delay time.Duration
+	ID    string        `json:"id"`
+	Data  string        `json:"data"`
+	Delay time.Duration `json:"delay"`
 }
 
 // NewSimpleJob creates a simple job (it's for example purpose)
@@ -25,19 +30,14 @@ func NewSimpleJob(id string, data string, delay time.Duration) *SimpleJob {
 
 // Execute Implements Job interface and it customs logic
 func (j *SimpleJob) Execute(ctx context.Context) error {
-	log.Printf("Starting job %s with data: %s", j.ID, j.Data)
-
 	timer := time.NewTimer(j.Delay)
 	defer timer.Stop()
 
 	select {
 	case <-timer.C:
-		result := fmt.Sprintf("Processed: %s", j.Data)
-		log.Printf("Completed job %s with result: %s", j.ID, result)
 		return nil
 
 	case <-ctx.Done():
-		log.Printf("Job %s was canceled: %v", j.ID, ctx.Err())
 		return ctx.Err()
 	}
 }
@@ -51,3 +51,22 @@ func (j *SimpleJob) Name() string {
 func (j *SimpleJob) Priority() int {
 	return 1
 }
+
+// JobType implements SerializableJob
+func (j *SimpleJob) JobType() string {
+	return SimpleJobType
+}
+
+// Marshal implements SerializableJob
+func (j *SimpleJob) Marshal() ([]byte, error) {
+	return json.Marshal(j)
+}
+
+// DecodeSimpleJob rebuilds a SimpleJob from its serialized payload
+func DecodeSimpleJob(payload []byte) (types.Job, error) {
+	job := &SimpleJob{}
+	if err := json.Unmarshal(payload, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
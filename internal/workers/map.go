@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// DefaultMapJobName es el nombre de los jobs de Map que no definen uno
+const DefaultMapJobName = "map"
+
+// MapOptions configura una ejecución de Map
+type MapOptions struct {
+	// Name es el nombre de los jobs de cada item; define la cola y el límite de
+	// tasa por prefijo igual que para cualquier otro job (por defecto DefaultMapJobName)
+	Name string
+	// Queue fuerza la cola con nombre de los jobs
+	Queue string
+	// Resource es el recurso downstream, para aplicar su límite de tasa
+	Resource string
+	// Priority es la prioridad de los jobs
+	Priority int
+	// Retry es la política de reintentos de cada item (por defecto la del pool)
+	Retry types.RetryPolicy
+	// Timeout es el tiempo máximo por item, reintentos incluidos (por defecto el del pool)
+	Timeout time.Duration
+	// Concurrency es la cantidad máxima de items en vuelo (por defecto la
+	// cantidad máxima de workers de la cola a la que van los jobs)
+	Concurrency int
+	// Ordered devuelve los resultados en el orden de los items en lugar del
+	// orden en que terminan
+	Ordered bool
+	// FailFast corta al primer error: deja de encolar items y cancela los que
+	// están en vuelo. Si no, se procesan todos y se devuelven todos los errores.
+	FailFast bool
+	// Progress se invoca cada vez que termina un item
+	Progress func(MapProgress)
+}
+
+// MapProgress es el avance de una ejecución de Map
+type MapProgress struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+}
+
+// MapResult es el resultado de un item de Map
+type MapResult[R any] struct {
+	// Index es la posición del item en la entrada
+	Index    int
+	Value    R
+	Err      error
+	Attempts int
+	// Duration es lo que tardó el job del item, reintentos incluidos
+	Duration   time.Duration
+	FinishedAt time.Time
+}
+
+// mapJob es el Job que se encola para procesar un item de Map
+type mapJob[T, R any] struct {
+	types.JobOutput[R]
+	ctx  context.Context
+	item T
+	fn   func(context.Context, T) (R, error)
+	opts *MapOptions
+}
+
+// Execute implementa la interfaz Job, cancelándose también si se cancela el Map
+func (j *mapJob[T, R]) Execute(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(j.ctx, cancel)
+	defer stop()
+
+	value, err := j.fn(ctx, j.item)
+	if err != nil {
+		return err
+	}
+	j.SetOutput(value)
+	return nil
+}
+
+// Name implementa la interfaz Job
+func (j *mapJob[T, R]) Name() string {
+	return j.opts.Name
+}
+
+// Priority implementa la interfaz Job
+func (j *mapJob[T, R]) Priority() int {
+	return j.opts.Priority
+}
+
+// RetryPolicy implementa la interfaz RetryableJob
+func (j *mapJob[T, R]) RetryPolicy() types.RetryPolicy {
+	return j.opts.Retry
+}
+
+// Timeout implementa la interfaz TimeoutJob
+func (j *mapJob[T, R]) Timeout() time.Duration {
+	return j.opts.Timeout
+}
+
+// ResourceKey implementa la interfaz ResourceJob
+func (j *mapJob[T, R]) ResourceKey() string {
+	return j.opts.Resource
+}
+
+// QueueName implementa la interfaz QueuedJob
+func (j *mapJob[T, R]) QueueName() string {
+	return j.opts.Queue
+}
+
+// Map aplica fn a cada item como un job del dispatcher, con a lo sumo
+// opts.Concurrency items en vuelo, y devuelve el resultado de cada uno. Los
+// items se encolan a medida que se libera lugar, con los reintentos, límites
+// de tasa y timeouts del pool.
+//
+// Con FailFast devuelve el primer error y los resultados de los items que
+// terminaron hasta ese momento; si no, devuelve todos los resultados y los
+// errores de los items juntos. Si ctx vence devuelve su error.
+func Map[T, R any](ctx context.Context, d *Dispatcher, items []T, fn func(context.Context, T) (R, error), opts MapOptions) ([]MapResult[R], error) {
+	if opts.Name == "" {
+		opts.Name = DefaultMapJobName
+	}
+	limit := opts.Concurrency
+	if limit <= 0 {
+		// Los jobs van a la cola que indique opts.Queue o el ruteo por nombre
+		pool, err := d.queues.route(&mapJob[T, R]{opts: &opts})
+		if err != nil {
+			return nil, err
+		}
+		_, limit = pool.Bounds()
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		results  = make([]MapResult[R], 0, len(items))
+		handles  = make(map[int]*JobHandle, limit)
+		finished = make(chan MapResult[R], limit)
+		progress = MapProgress{Total: len(items)}
+		errs     []error
+		next     int
+	)
+
+	// record guarda el resultado de un item y devuelve false si hay que cortar
+	record := func(result MapResult[R]) bool {
+		results = append(results, result)
+		progress.Completed++
+		if result.Err != nil {
+			progress.Failed++
+			errs = append(errs, fmt.Errorf("item %d: %w", result.Index, result.Err))
+		} else {
+			progress.Succeeded++
+		}
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		return result.Err == nil || !opts.FailFast
+	}
+
+	stopped := false
+	for !stopped && (next < len(items) || len(handles) > 0) {
+		if next < len(items) && len(handles) < limit {
+			index := next
+			next++
+
+			job := &mapJob[T, R]{ctx: ctx, item: items[index], fn: fn, opts: &opts}
+			handle, err := d.SubmitWithContext(ctx, job)
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				stopped = !record(MapResult[R]{Index: index, Err: err, FinishedAt: time.Now()})
+				continue
+			}
+
+			handles[index] = handle
+			go func() {
+				select {
+				case <-handle.Done():
+				case <-ctx.Done():
+					return
+				}
+				// Ya terminó: Wait devuelve el resultado sin bloquear
+				result, _ := handle.Wait(context.Background())
+				finished <- mapResult[R](index, result)
+			}()
+			continue
+		}
+
+		select {
+		case result := <-finished:
+			delete(handles, result.Index)
+			stopped = !record(result)
+		case <-ctx.Done():
+			stopped = true
+		}
+	}
+
+	// Lo que quedó en vuelo se cancela: sus resultados ya no se esperan
+	for _, handle := range handles {
+		handle.Cancel()
+	}
+
+	if opts.Ordered {
+		slices.SortFunc(results, func(a, b MapResult[R]) int {
+			return a.Index - b.Index
+		})
+	}
+
+	if err := ctx.Err(); err != nil && (len(errs) == 0 || !opts.FailFast) {
+		return results, err
+	}
+	if opts.FailFast && len(errs) > 0 {
+		return results, errs[0]
+	}
+	return results, errors.Join(errs...)
+}
+
+// mapResult convierte el resultado del job de un item
+func mapResult[R any](index int, result types.JobResult) MapResult[R] {
+	mapped := MapResult[R]{
+		Index:      index,
+		Err:        result.Error,
+		Attempts:   result.Attempts,
+		Duration:   result.Duration,
+		FinishedAt: result.Timestamp,
+	}
+	if value, ok := result.Data.(R); ok {
+		mapped.Value = value
+	}
+	return mapped
+}
@@ -0,0 +1,55 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapDefaultConcurrencyFollowsRoutedQueue(t *testing.T) {
+	tests := []struct {
+		name string
+		opts MapOptions
+	}{
+		{"queue option", MapOptions{Queue: "slow"}},
+		{"name routing", MapOptions{Name: "slow-item"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// La cola rechaza los envíos si hay más items en vuelo que workers
+			d := NewDispatcher(context.Background(), 4, 10,
+				WithNamedQueue("slow", QueueConfig{
+					Workers:     1,
+					Size:        1,
+					Overflow:    OverflowReject,
+					JobPrefixes: []string{"slow-"},
+				}),
+			)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			items := []int{1, 2, 3, 4, 5}
+			results, err := Map(context.Background(), d, items, func(ctx context.Context, n int) (int, error) {
+				time.Sleep(5 * time.Millisecond)
+				return n * 2, nil
+			}, tt.opts)
+
+			assert.NoError(t, err)
+			assert.Len(t, results, len(items))
+		})
+	}
+}
+
+func TestMapRejectsUnknownQueue(t *testing.T) {
+	d := NewDispatcher(context.Background(), 2, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	_, err := Map(context.Background(), d, []int{1}, func(ctx context.Context, n int) (int, error) {
+		return n, nil
+	}, MapOptions{Queue: "missing"})
+	assert.ErrorContains(t, err, "unknown queue")
+}
@@ -0,0 +1,360 @@
+package worker
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+const (
+	// throughputWindow es la ventana, en segundos, sobre la que se calcula el throughput
+	throughputWindow = 60
+	// maxMetricJobs acota la cantidad de nombres de job distintos con contadores propios
+	maxMetricJobs = 1000
+	// otherJobsMetric agrupa los jobs que exceden maxMetricJobs
+	otherJobsMetric = "other"
+)
+
+// latencyBuckets son los límites superiores de los histogramas de latencia
+var latencyBuckets = []time.Duration{
+	time.Millisecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	5 * time.Minute,
+}
+
+// histogram cuenta duraciones en buckets fijos sin bloquear a los workers
+type histogram struct {
+	counts []atomic.Int64
+	sum    atomic.Int64
+}
+
+// HistogramBucket es la cantidad de observaciones hasta UpperBound (0 = sin límite)
+// que no entraron en el bucket anterior
+type HistogramBucket struct {
+	UpperBound time.Duration `json:"upper_bound"`
+	Count      int64         `json:"count"`
+}
+
+// HistogramSnapshot es una copia de un histograma de latencias con percentiles estimados
+// a partir de los límites de los buckets
+type HistogramSnapshot struct {
+	Count   int64             `json:"count"`
+	Sum     time.Duration     `json:"sum"`
+	Mean    time.Duration     `json:"mean"`
+	P50     time.Duration     `json:"p50"`
+	P95     time.Duration     `json:"p95"`
+	P99     time.Duration     `json:"p99"`
+	Buckets []HistogramBucket `json:"buckets"`
+}
+
+func newHistogram() *histogram {
+	return &histogram{counts: make([]atomic.Int64, len(latencyBuckets)+1)}
+}
+
+// observe registra una duración
+func (h *histogram) observe(d time.Duration) {
+	i := sort.Search(len(latencyBuckets), func(i int) bool { return d <= latencyBuckets[i] })
+	h.counts[i].Add(1)
+	h.sum.Add(int64(d))
+}
+
+// snapshot devuelve una copia del histograma
+func (h *histogram) snapshot() HistogramSnapshot {
+	snapshot := HistogramSnapshot{Buckets: newHistogramBuckets()}
+	for i := range h.counts {
+		snapshot.Buckets[i].Count = h.counts[i].Load()
+	}
+	snapshot.Sum = time.Duration(h.sum.Load())
+	snapshot.summarize()
+	return snapshot
+}
+
+// newHistogramBuckets devuelve los buckets vacíos con sus límites
+func newHistogramBuckets() []HistogramBucket {
+	buckets := make([]HistogramBucket, len(latencyBuckets)+1)
+	for i, bound := range latencyBuckets {
+		buckets[i].UpperBound = bound
+	}
+	return buckets
+}
+
+// mergeHistograms suma los buckets de varios histogramas
+func mergeHistograms(all []HistogramSnapshot) HistogramSnapshot {
+	merged := HistogramSnapshot{Buckets: newHistogramBuckets()}
+	for _, snapshot := range all {
+		for i, bucket := range snapshot.Buckets {
+			merged.Buckets[i].Count += bucket.Count
+		}
+		merged.Sum += snapshot.Sum
+	}
+	merged.summarize()
+	return merged
+}
+
+// summarize calcula el total, la media y los percentiles a partir de los buckets
+func (s *HistogramSnapshot) summarize() {
+	s.Count = 0
+	for _, bucket := range s.Buckets {
+		s.Count += bucket.Count
+	}
+
+	if s.Count > 0 {
+		s.Mean = s.Sum / time.Duration(s.Count)
+		s.P50 = s.quantile(0.50)
+		s.P95 = s.quantile(0.95)
+		s.P99 = s.quantile(0.99)
+	}
+}
+
+// quantile devuelve el límite del bucket que contiene el cuantil q. Para el
+// último bucket (sin límite) devuelve el mayor límite conocido.
+func (s HistogramSnapshot) quantile(q float64) time.Duration {
+	target := int64(q * float64(s.Count))
+	var cumulative int64
+	for _, bucket := range s.Buckets {
+		cumulative += bucket.Count
+		if cumulative > target && bucket.UpperBound > 0 {
+			return bucket.UpperBound
+		}
+	}
+	return latencyBuckets[len(latencyBuckets)-1]
+}
+
+// JobCounts son los resultados acumulados de un tipo de job
+type JobCounts struct {
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	Cancelled int64 `json:"cancelled"`
+	Panicked  int64 `json:"panicked"`
+}
+
+// jobCounters son los contadores de un tipo de job
+type jobCounters struct {
+	succeeded atomic.Int64
+	failed    atomic.Int64
+	cancelled atomic.Int64
+	panicked  atomic.Int64
+}
+
+// PoolMetrics es una copia de las métricas agregadas del pool
+type PoolMetrics struct {
+	Submitted int64 `json:"submitted"`
+	// Deduplicated son los envíos que se unieron a un job existente con la misma clave
+	Deduplicated int64 `json:"deduplicated"`
+	// Rejected son los envíos que no entraron por tener la cola llena
+	Rejected int64 `json:"rejected"`
+	// Dropped son los jobs encolados descartados por OverflowDropOldest
+	Dropped int64 `json:"dropped"`
+	// Spilled son los jobs desviados a la cola de desborde por OverflowSpillToDisk
+	Spilled   int64 `json:"spilled"`
+	Succeeded int64 `json:"succeeded"`
+	Failed    int64 `json:"failed"`
+	Cancelled int64 `json:"cancelled"`
+	Panicked  int64 `json:"panicked"`
+	// InFlight es la cantidad de jobs ejecutándose en este momento
+	InFlight int64 `json:"in_flight"`
+	// Throughput es la cantidad de jobs terminados por segundo en el último minuto
+	Throughput float64 `json:"throughput"`
+	// WaitTime mide el tiempo en cola y ExecTime el de ejecución (incluidos reintentos)
+	WaitTime HistogramSnapshot `json:"wait_time"`
+	ExecTime HistogramSnapshot `json:"exec_time"`
+	// Jobs agrupa los resultados por tipo de job (JobType si es serializable, si no Name)
+	Jobs    map[string]JobCounts `json:"jobs"`
+	Workers []WorkerMetrics      `json:"workers"`
+}
+
+// poolMetrics acumula las métricas del pool; los workers las actualizan sin
+// tomar locks salvo al registrar un tipo de job nuevo
+type poolMetrics struct {
+	submitted    atomic.Int64
+	deduplicated atomic.Int64
+	rejected     atomic.Int64
+	dropped      atomic.Int64
+	spilled      atomic.Int64
+	inFlight     atomic.Int64
+	waitTime     *histogram
+	execTime     *histogram
+
+	mu   sync.RWMutex
+	jobs map[string]*jobCounters
+
+	rateMu     sync.Mutex
+	rateSlots  [throughputWindow]int64
+	rateSecond int64
+}
+
+func newPoolMetrics() *poolMetrics {
+	return &poolMetrics{
+		waitTime: newHistogram(),
+		execTime: newHistogram(),
+		jobs:     make(map[string]*jobCounters),
+	}
+}
+
+// metricName agrupa los jobs por tipo para no crear un contador por instancia
+func metricName(job Job) string {
+	if serializable, ok := job.(types.SerializableJob); ok {
+		return serializable.JobType()
+	}
+	return job.Name()
+}
+
+// counters devuelve los contadores del tipo de job, creándolos si hace falta
+func (m *poolMetrics) counters(name string) *jobCounters {
+	m.mu.RLock()
+	counters, ok := m.jobs[name]
+	m.mu.RUnlock()
+	if ok {
+		return counters
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if counters, ok := m.jobs[name]; ok {
+		return counters
+	}
+	if len(m.jobs) >= maxMetricJobs {
+		name = otherJobsMetric
+		if counters, ok := m.jobs[name]; ok {
+			return counters
+		}
+	}
+	counters = &jobCounters{}
+	m.jobs[name] = counters
+	return counters
+}
+
+// started registra que un item salió de la cola y empezó a ejecutarse
+func (m *poolMetrics) started(item *QueueItem, now time.Time) {
+	m.inFlight.Add(1)
+	if !item.EnqueuedAt.IsZero() {
+		m.waitTime.observe(now.Sub(item.EnqueuedAt))
+	}
+}
+
+// finished registra el resultado de un item
+func (m *poolMetrics) finished(item *QueueItem, result types.JobResult) {
+	m.inFlight.Add(-1)
+	m.execTime.observe(result.Duration)
+
+	counters := m.counters(metricName(item.Job))
+	switch {
+	case result.Success:
+		counters.succeeded.Add(1)
+	case errors.Is(result.Error, ErrJobCancelled):
+		counters.cancelled.Add(1)
+	case result.Panicked:
+		counters.panicked.Add(1)
+		counters.failed.Add(1)
+	default:
+		counters.failed.Add(1)
+	}
+	m.tick(result.Timestamp)
+}
+
+// cancelled registra un item cancelado antes de ejecutarse
+func (m *poolMetrics) cancelled(item *QueueItem) {
+	m.counters(metricName(item.Job)).cancelled.Add(1)
+}
+
+// tick suma un job terminado en el slot del segundo correspondiente
+func (m *poolMetrics) tick(at time.Time) {
+	second := at.Unix()
+
+	m.rateMu.Lock()
+	defer m.rateMu.Unlock()
+
+	m.advance(second)
+	m.rateSlots[second%throughputWindow]++
+}
+
+// advance limpia los slots de los segundos que quedaron fuera de la ventana (requiere rateMu)
+func (m *poolMetrics) advance(second int64) {
+	if second <= m.rateSecond {
+		return
+	}
+	for s := max(m.rateSecond+1, second-throughputWindow+1); s <= second; s++ {
+		m.rateSlots[s%throughputWindow] = 0
+	}
+	m.rateSecond = second
+}
+
+// throughput devuelve los jobs terminados por segundo en la ventana
+func (m *poolMetrics) throughput(now time.Time) float64 {
+	m.rateMu.Lock()
+	defer m.rateMu.Unlock()
+
+	m.advance(now.Unix())
+	var total int64
+	for _, count := range m.rateSlots {
+		total += count
+	}
+	return float64(total) / throughputWindow
+}
+
+// snapshot copia las métricas agregadas (sin las de cada worker)
+func (m *poolMetrics) snapshot(now time.Time) PoolMetrics {
+	snapshot := PoolMetrics{
+		Submitted:    m.submitted.Load(),
+		Deduplicated: m.deduplicated.Load(),
+		Rejected:     m.rejected.Load(),
+		Dropped:      m.dropped.Load(),
+		Spilled:      m.spilled.Load(),
+		InFlight:     m.inFlight.Load(),
+		Throughput:   m.throughput(now),
+		WaitTime:     m.waitTime.snapshot(),
+		ExecTime:     m.execTime.snapshot(),
+		Jobs:         make(map[string]JobCounts),
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for name, counters := range m.jobs {
+		counts := JobCounts{
+			Succeeded: counters.succeeded.Load(),
+			Failed:    counters.failed.Load(),
+			Cancelled: counters.cancelled.Load(),
+			Panicked:  counters.panicked.Load(),
+		}
+		snapshot.Jobs[name] = counts
+		snapshot.Succeeded += counts.Succeeded
+		snapshot.Failed += counts.Failed
+		snapshot.Cancelled += counts.Cancelled
+		snapshot.Panicked += counts.Panicked
+	}
+	return snapshot
+}
+
+// Metrics devuelve una copia de las métricas agregadas del pool y de cada worker
+func (p *Pool) Metrics() PoolMetrics {
+	now := time.Now()
+	snapshot := p.metrics.snapshot(now)
+
+	p.mu.Lock()
+	workers := append([]*Worker(nil), p.workers...)
+	p.mu.Unlock()
+
+	snapshot.Workers = make([]WorkerMetrics, 0, len(workers))
+	for _, worker := range workers {
+		snapshot.Workers = append(snapshot.Workers, worker.metricsAt(now))
+	}
+	return snapshot
+}
@@ -0,0 +1,139 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// observeN registra n veces la duración d en el histograma
+func observeN(h *histogram, n int, d time.Duration) {
+	for range n {
+		h.observe(d)
+	}
+}
+
+func TestHistogramQuantiles(t *testing.T) {
+	h := newHistogram()
+	observeN(h, 60, time.Millisecond)
+	observeN(h, 35, 20*time.Millisecond)
+	observeN(h, 4, 200*time.Millisecond)
+	observeN(h, 1, 10*time.Minute)
+
+	snapshot := h.snapshot()
+	assert.Equal(t, int64(100), snapshot.Count)
+	assert.Equal(t, 60*time.Millisecond+700*time.Millisecond+800*time.Millisecond+10*time.Minute, snapshot.Sum)
+	assert.Equal(t, snapshot.Sum/100, snapshot.Mean)
+	assert.Equal(t, time.Millisecond, snapshot.P50)
+	assert.Equal(t, 250*time.Millisecond, snapshot.P95)
+	// El último bucket no tiene límite: se informa el mayor conocido
+	assert.Equal(t, 5*time.Minute, snapshot.P99)
+
+	assert.Len(t, snapshot.Buckets, len(latencyBuckets)+1)
+	assert.Equal(t, HistogramBucket{UpperBound: time.Millisecond, Count: 60}, snapshot.Buckets[0])
+	assert.Equal(t, HistogramBucket{UpperBound: 25 * time.Millisecond, Count: 35}, snapshot.Buckets[3])
+	assert.Equal(t, HistogramBucket{UpperBound: 250 * time.Millisecond, Count: 4}, snapshot.Buckets[6])
+	assert.Equal(t, HistogramBucket{Count: 1}, snapshot.Buckets[len(latencyBuckets)])
+}
+
+func TestEmptyHistogram(t *testing.T) {
+	for name, snapshot := range map[string]HistogramSnapshot{
+		"snapshot": newHistogram().snapshot(),
+		"merged":   mergeHistograms(nil),
+	} {
+		t.Run(name, func(t *testing.T) {
+			assert.Zero(t, snapshot.Count)
+			assert.Zero(t, snapshot.Sum)
+			assert.Zero(t, snapshot.Mean)
+			assert.Zero(t, snapshot.P50)
+			assert.Zero(t, snapshot.P95)
+			assert.Zero(t, snapshot.P99)
+			assert.Len(t, snapshot.Buckets, len(latencyBuckets)+1)
+		})
+	}
+}
+
+func TestMergeMetricsAcrossQueues(t *testing.T) {
+	fast, slow := newHistogram(), newHistogram()
+	observeN(fast, 10, time.Millisecond)
+	observeN(slow, 10, time.Second)
+
+	merged := mergeMetrics([]PoolMetrics{
+		{
+			Submitted: 10,
+			Succeeded: 9,
+			Failed:    1,
+			ExecTime:  fast.snapshot(),
+			Jobs: map[string]JobCounts{
+				"price":  {Succeeded: 5},
+				"report": {Succeeded: 4, Failed: 1},
+			},
+			Workers: []WorkerMetrics{{WorkerID: 1}},
+		},
+		{
+			Submitted: 10,
+			Succeeded: 8,
+			Cancelled: 2,
+			ExecTime:  slow.snapshot(),
+			Jobs: map[string]JobCounts{
+				"price": {Succeeded: 8, Cancelled: 2},
+			},
+			Workers: []WorkerMetrics{{WorkerID: 1}, {WorkerID: 2}},
+		},
+	})
+
+	assert.Equal(t, int64(20), merged.Submitted)
+	assert.Equal(t, int64(17), merged.Succeeded)
+	assert.Equal(t, int64(1), merged.Failed)
+	assert.Equal(t, int64(2), merged.Cancelled)
+	assert.Equal(t, map[string]JobCounts{
+		"price":  {Succeeded: 13, Cancelled: 2},
+		"report": {Succeeded: 4, Failed: 1},
+	}, merged.Jobs)
+	assert.Len(t, merged.Workers, 3)
+
+	// Los percentiles se recalculan sobre los buckets sumados, no se promedian
+	assert.Equal(t, int64(20), merged.ExecTime.Count)
+	assert.Equal(t, (10*time.Millisecond+10*time.Second)/20, merged.ExecTime.Mean)
+	assert.Equal(t, time.Second, merged.ExecTime.P50)
+	assert.Equal(t, time.Second, merged.ExecTime.P99)
+	assert.Zero(t, merged.WaitTime.Count)
+}
+
+func TestDispatcherStatsMergeQueueMetrics(t *testing.T) {
+	d := NewDispatcher(context.Background(), 2, 10, WithNamedQueue("reports", QueueConfig{
+		Workers:     1,
+		Size:        10,
+		JobPrefixes: []string{"report-"},
+	}))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	for i := range 3 {
+		_, err := d.Submit(newTestJob(fmt.Sprintf("price-%d", i), nil))
+		assert.NoError(t, err)
+	}
+	for i := range 2 {
+		_, err := d.Submit(newTestJob(fmt.Sprintf("report-%d", i), func(context.Context) error {
+			return fmt.Errorf("boom")
+		}))
+		assert.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		metrics := d.Stats().Metrics
+		return metrics.Succeeded+metrics.Failed == 5
+	}, 2*time.Second, 5*time.Millisecond)
+
+	stats := d.Stats()
+	assert.Equal(t, int64(5), stats.Metrics.Submitted)
+	assert.Equal(t, int64(3), stats.Metrics.Succeeded)
+	assert.Equal(t, int64(2), stats.Metrics.Failed)
+	assert.Equal(t, int64(5), stats.Metrics.ExecTime.Count)
+	assert.Equal(t, stats.Queues[DefaultQueueName].Metrics.ExecTime.Count+stats.Queues["reports"].Metrics.ExecTime.Count,
+		stats.Metrics.ExecTime.Count)
+	assert.Len(t, stats.Metrics.Workers, 3)
+}
@@ -0,0 +1,132 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// ErrAttemptTimeout es el error de un intento que excedió el límite de
+// AttemptTimeoutMiddleware
+var ErrAttemptTimeout = errors.New("job attempt timed out")
+
+// Handler ejecuta un intento de un job
+type Handler func(ctx context.Context, job Job) error
+
+// Middleware envuelve la ejecución de los jobs para agregar comportamiento
+// transversal (logging, métricas, tracing, valores de contexto) sin tocar
+// cada job. Se aplica a cada intento, dentro de los reintentos y del límite
+// de tasa; un panic en un middleware se recupera igual que uno del job.
+type Middleware func(next Handler) Handler
+
+// jobMiddleware son los middlewares de los jobs cuyo nombre empieza con prefix
+type jobMiddleware struct {
+	prefix     string
+	middleware []Middleware
+}
+
+// WithMiddleware agrega middlewares a los jobs de todas las colas. Son los
+// más externos de la cadena y se aplican en el orden en que se registran.
+func WithMiddleware(middleware ...Middleware) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// WithJobMiddleware agrega middlewares a los jobs cuyo nombre empieza con
+// prefix, en cualquier cola. Son los más internos de la cadena.
+func WithJobMiddleware(prefix string, middleware ...Middleware) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.jobMiddleware = append(c.jobMiddleware, jobMiddleware{prefix: prefix, middleware: middleware})
+	}
+}
+
+// WithPoolMiddleware agrega middlewares a los jobs del pool. En un dispatcher
+// sirve para los middlewares de una cola: van dentro de los de WithMiddleware
+// y fuera de los de WithJobMiddleware.
+func WithPoolMiddleware(middleware ...Middleware) PoolOption {
+	return func(c *poolConfig) {
+		c.middleware = append(c.middleware, middleware...)
+	}
+}
+
+// withSharedMiddleware comparte con el pool los middlewares del dispatcher
+func withSharedMiddleware(middleware []Middleware, byJob []jobMiddleware) PoolOption {
+	return func(c *poolConfig) {
+		c.sharedMiddleware = middleware
+		c.jobMiddleware = byJob
+	}
+}
+
+// handler arma la cadena de middlewares del job alrededor de Execute
+func (p *Pool) handler(job Job) Handler {
+	chain := make([]Middleware, 0, len(p.sharedMiddleware)+len(p.middleware))
+	chain = append(chain, p.sharedMiddleware...)
+	chain = append(chain, p.middleware...)
+	for _, byJob := range p.jobMiddleware {
+		if strings.HasPrefix(job.Name(), byJob.prefix) {
+			chain = append(chain, byJob.middleware...)
+		}
+	}
+
+	handler := Handler(func(ctx context.Context, job Job) error {
+		return job.Execute(ctx)
+	})
+	for i := len(chain) - 1; i >= 0; i-- {
+		handler = chain[i](handler)
+	}
+	return handler
+}
+
+// LoggingMiddleware registra el inicio, el fin y la duración de cada intento,
+// con el ID de la request que originó el job si lo hay
+func LoggingMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job Job) error {
+			start := time.Now()
+			name := job.Name()
+			if id := types.RequestID(ctx); id != "" {
+				name += " [request_id=" + id + "]"
+			}
+			log.Printf("Job %s started", name)
+
+			err := next(ctx, job)
+			if err != nil {
+				log.Printf("Job %s failed after %v: %v", name, time.Since(start), err)
+			} else {
+				log.Printf("Job %s finished in %v", name, time.Since(start))
+			}
+			return err
+		}
+	}
+}
+
+// AttemptTimeoutMiddleware limita la duración de cada intento, a diferencia
+// de types.TimeoutJob que limita el total incluidos los reintentos. Un intento
+// que excede el límite falla con ErrAttemptTimeout, que a diferencia de
+// context.DeadlineExceeded se reintenta según la política del job. El error
+// del job queda envuelto, así un types.Permanent sigue sin reintentarse; solo
+// se reemplaza context.DeadlineExceeded.
+func AttemptTimeoutMiddleware(timeout time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, job Job) error {
+			attemptCtx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			err := next(attemptCtx, job)
+			if err != nil && ctx.Err() == nil && errors.Is(attemptCtx.Err(), context.DeadlineExceeded) {
+				// Solo venció el intento: el job todavía puede reintentarse
+				if errors.Is(err, context.DeadlineExceeded) {
+					return fmt.Errorf("%w after %v: %v", ErrAttemptTimeout, timeout, err)
+				}
+				return fmt.Errorf("%w after %v: %w", ErrAttemptTimeout, timeout, err)
+			}
+			return err
+		}
+	}
+}
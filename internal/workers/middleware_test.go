@@ -0,0 +1,90 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttemptTimeoutMiddlewareRetriesEachAttempt(t *testing.T) {
+	tests := []struct {
+		name     string
+		slow     int32
+		attempts int
+		success  bool
+	}{
+		{"every attempt times out", 3, 3, false},
+		{"recovers after a slow attempt", 1, 2, true},
+		{"fast attempt", 0, 1, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10,
+				WithMiddleware(AttemptTimeoutMiddleware(20*time.Millisecond)))
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			var calls atomic.Int32
+			job := &retryJob{
+				testJob: newTestJob("slow", func(ctx context.Context) error {
+					if calls.Add(1) > tt.slow {
+						return nil
+					}
+					<-ctx.Done()
+					return ctx.Err()
+				}),
+				policy: types.ConstantRetry(3, time.Millisecond),
+			}
+
+			handle, err := d.Submit(job)
+			assert.NoError(t, err)
+
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			result, err := handle.Wait(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.attempts, result.Attempts)
+			if !tt.success {
+				assert.ErrorIs(t, result.Error, ErrAttemptTimeout)
+			}
+		})
+	}
+}
+
+func TestAttemptTimeoutMiddlewareKeepsPermanentErrors(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10,
+		WithMiddleware(AttemptTimeoutMiddleware(20*time.Millisecond)))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	notFound := errors.New("offer not found")
+	job := &retryJob{
+		testJob: newTestJob("permanent", func(ctx context.Context) error {
+			<-ctx.Done()
+			return types.Permanent(notFound)
+		}),
+		policy: types.ConstantRetry(3, time.Millisecond),
+	}
+
+	handle, err := d.Submit(job)
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := handle.Wait(ctx)
+	assert.NoError(t, err)
+
+	assert.False(t, result.Success)
+	assert.Equal(t, 1, result.Attempts)
+	assert.ErrorIs(t, result.Error, ErrAttemptTimeout)
+	assert.ErrorIs(t, result.Error, notFound)
+}
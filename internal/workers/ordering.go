@@ -0,0 +1,206 @@
+package worker
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// keySequencer serializa los jobs que comparten clave de orden: solo uno por
+// clave está en la cola o en ejecución y el resto espera acá, en orden de
+// envío, hasta que termine el anterior. Las claves distintas siguen en paralelo.
+// El dispatcher comparte un único sequencer entre todas sus colas, así el
+// orden por clave se respeta aunque los jobs vayan a colas distintas.
+type keySequencer struct {
+	mu sync.Mutex
+	// waiting tiene una entrada por cada clave con un job activo
+	waiting map[string][]sequencedItem
+}
+
+// sequencedItem es un item que espera su turno junto con el pool que lo recibió
+type sequencedItem struct {
+	item *QueueItem
+	pool *Pool
+}
+
+func newKeySequencer() *keySequencer {
+	return &keySequencer{waiting: make(map[string][]sequencedItem)}
+}
+
+// withKeySequencer comparte con el pool el sequencer del dispatcher
+func withKeySequencer(sequencer *keySequencer) PoolOption {
+	return func(c *poolConfig) {
+		c.ordering = sequencer
+	}
+}
+
+// heldQueue la implementan las colas durables que pueden persistir un item
+// sin ponerlo en la cola, para que los jobs que esperan su turno por clave de
+// orden también sobrevivan un reinicio
+type heldQueue interface {
+	// Persist guarda el item sin encolarlo
+	Persist(item *QueueItem) error
+	// PushPersisted encola un item ya guardado con Persist, bloqueando
+	// mientras la cola esté llena
+	PushPersisted(ctx context.Context, item *QueueItem) error
+}
+
+// orderingKey devuelve la clave de orden del job ("" si no declara una)
+func orderingKey(job Job) string {
+	if ordered, ok := job.(types.OrderedJob); ok {
+		return ordered.OrderingKey()
+	}
+	return ""
+}
+
+// admit devuelve true si el item puede encolarse ya, o lo retiene detrás del
+// job activo con su misma clave. Antes de retenerlo llama a hold (si no es
+// nil) con el sequencer bloqueado, así el item no puede avanzar antes de que
+// hold termine; si hold falla, no lo retiene y devuelve el error.
+func (s *keySequencer) admit(pool *Pool, item *QueueItem, hold func(*QueueItem) error) (bool, error) {
+	key := orderingKey(item.Job)
+	if key == "" {
+		return true, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting, active := s.waiting[key]
+	if !active {
+		s.waiting[key] = nil
+		return true, nil
+	}
+
+	if hold != nil {
+		if err := hold(item); err != nil {
+			return false, err
+		}
+	}
+	s.waiting[key] = append(waiting, sequencedItem{item: item, pool: pool})
+	return false, nil
+}
+
+// next libera la clave del item terminado y devuelve el siguiente a encolar
+// junto con su pool
+func (s *keySequencer) next(item *QueueItem) (sequencedItem, bool) {
+	key := orderingKey(item.Job)
+	if key == "" {
+		return sequencedItem{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting, active := s.waiting[key]
+	if !active {
+		return sequencedItem{}, false
+	}
+	if len(waiting) == 0 {
+		delete(s.waiting, key)
+		return sequencedItem{}, false
+	}
+	s.waiting[key] = waiting[1:]
+	return waiting[0], true
+}
+
+// remove quita un item que espera su turno
+func (s *keySequencer) remove(id string) (*QueueItem, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, waiting := range s.waiting {
+		for i, held := range waiting {
+			if held.item.ID == id {
+				s.waiting[key] = append(waiting[:i:i], waiting[i+1:]...)
+				return held.item, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// takeAll quita y devuelve todos los items del pool que esperan su turno. La
+// clave sigue activa: lo que espera detrás en otros pools no se adelanta.
+func (s *keySequencer) takeAll(pool *Pool) []*QueueItem {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	items := make([]*QueueItem, 0)
+	for key, waiting := range s.waiting {
+		kept := waiting[:0]
+		for _, held := range waiting {
+			if held.pool == pool {
+				items = append(items, held.item)
+			} else {
+				kept = append(kept, held)
+			}
+		}
+		s.waiting[key] = kept
+	}
+	return items
+}
+
+// len devuelve cuántos items del pool esperan su turno
+func (s *keySequencer) len(pool *Pool) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	waiting := 0
+	for _, items := range s.waiting {
+		for _, held := range items {
+			if held.pool == pool {
+				waiting++
+			}
+		}
+	}
+	return waiting
+}
+
+// advanceKey encola el siguiente job con la misma clave de orden que el item
+// terminado, en el pool que lo recibió. Se encola en otra goroutine para que
+// un worker nunca se bloquee con la cola llena. Si ese pool ya se está
+// deteniendo, el job termina enseguida como abandonado (con una cola durable
+// sigue persistido) y la clave pasa al siguiente.
+func (p *Pool) advanceKey(item *QueueItem) {
+	next, ok := p.ordering.next(item)
+	if !ok {
+		return
+	}
+
+	pool := next.pool
+	spawned := pool.goUnlessStopping(func() {
+		if err := pool.pushHeld(next.item); err != nil {
+			pool.abandon(next.item)
+			pool.release()
+			pool.advanceKey(next.item)
+		}
+	})
+	if !spawned {
+		log.Printf("Worker pool %s: abandoned job %s waiting for its ordering key, the pool is stopping", pool.name, next.item.Job.Name())
+		pool.finishAbandoned(next.item)
+		pool.release()
+		pool.advanceKey(next.item)
+	}
+}
+
+// admit deja pasar el item si no hay otro activo con su clave de orden, o lo
+// retiene detrás de ese. Con una cola durable el item retenido se persiste,
+// así sobrevive un reinicio aunque todavía no esté en la cola.
+func (p *Pool) admit(item *QueueItem) (bool, error) {
+	var hold func(*QueueItem) error
+	if durable, ok := p.jobQueue.(heldQueue); ok {
+		hold = durable.Persist
+	}
+	return p.ordering.admit(p, item, hold)
+}
+
+// pushHeld encola un item que esperaba su turno por clave de orden
+func (p *Pool) pushHeld(item *QueueItem) error {
+	if durable, ok := p.jobQueue.(heldQueue); ok {
+		return durable.PushPersisted(p.ctx, item)
+	}
+	return p.jobQueue.Push(p.ctx, item)
+}
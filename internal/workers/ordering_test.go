@@ -0,0 +1,158 @@
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// orderedJob es un testJob con clave de orden que se puede persistir
+type orderedJob struct {
+	*testJob
+	key string
+}
+
+// orderedJobType es el tipo con el que se registra orderedJob
+const orderedJobType = "ordered"
+
+func (j *orderedJob) OrderingKey() string { return j.key }
+func (j *orderedJob) JobType() string     { return orderedJobType }
+
+func (j *orderedJob) Marshal() ([]byte, error) {
+	return json.Marshal([]string{j.name, j.key})
+}
+
+func TestOrderingKeySpansQueues(t *testing.T) {
+	d := NewDispatcher(context.Background(), 2, 10, WithNamedQueue("slow", QueueConfig{
+		Workers:     1,
+		Size:        10,
+		JobPrefixes: []string{"slow-"},
+	}))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	release := make(chan struct{})
+	first, err := d.Submit(&orderedJob{testJob: newTestJob("slow-first", func(context.Context) error {
+		<-release
+		return nil
+	}), key: "offer:1"})
+	assert.NoError(t, err)
+	waitStatus(t, func() JobStatus { return first.Status().Status }, JobRunning)
+
+	second, err := d.Submit(&orderedJob{testJob: newTestJob("second", nil), key: "offer:1"})
+	assert.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, JobQueued, second.Status().Status)
+
+	close(release)
+	waitStatus(t, func() JobStatus { return second.Status().Status }, JobSucceeded)
+	assert.True(t, second.Status().StartedAt.After(first.Status().FinishedAt) ||
+		second.Status().StartedAt.Equal(first.Status().FinishedAt))
+}
+
+func TestHeldOrderedJobsSurviveRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	registry := NewJobRegistry()
+	registry.Register(orderedJobType, func(payload []byte) (types.Job, error) {
+		var fields []string
+		if err := json.Unmarshal(payload, &fields); err != nil {
+			return nil, err
+		}
+		return &orderedJob{testJob: newTestJob(fields[0], nil), key: fields[1]}, nil
+	})
+
+	queue, err := NewFileQueue(path, 10, registry)
+	assert.NoError(t, err)
+	pool := NewWorkerPool(1, 10, WithQueue(queue))
+	pool.Pause()
+	assert.NoError(t, pool.Start())
+	for _, name := range []string{"first", "second", "third"} {
+		assert.NoError(t, pool.Submit(&orderedJob{testJob: newTestJob(name, nil), key: "offer:1"}))
+	}
+	assert.Equal(t, 1, queue.Len())
+	pool.Stop()
+
+	reopened, err := NewFileQueue(path, 10, registry)
+	assert.NoError(t, err)
+	restarted := NewWorkerPool(1, 10, WithQueue(reopened))
+	restarted.Pause()
+	assert.NoError(t, restarted.Start())
+	defer restarted.Stop()
+
+	// Solo el primero vuelve a la cola; los demás esperan su turno otra vez
+	assert.Equal(t, 1, reopened.Len())
+	assert.Equal(t, 3, restarted.Pending())
+
+	restarted.Resume()
+	assert.Eventually(t, func() bool {
+		return restarted.Metrics().Succeeded == 3
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestOrderingKeyHeldOnStoppingQueue(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10, WithNamedQueue("q", QueueConfig{
+		Workers:     1,
+		Size:        10,
+		JobPrefixes: []string{"q-"},
+	}))
+	assert.NoError(t, d.Start())
+
+	// "q" no puede terminar de detenerse mientras su worker siga ocupado
+	unblock := make(chan struct{})
+	blocking, err := d.Submit(newTestJob("q-blocking", func(context.Context) error {
+		<-unblock
+		return nil
+	}))
+	assert.NoError(t, err)
+	waitStatus(t, func() JobStatus { return blocking.Status().Status }, JobRunning)
+
+	release := make(chan struct{})
+	first, err := d.Submit(&orderedJob{testJob: newTestJob("first", func(context.Context) error {
+		<-release
+		return nil
+	}), key: "offer:1"})
+	assert.NoError(t, err)
+	waitStatus(t, func() JobStatus { return first.Status().Status }, JobRunning)
+
+	second, err := d.Submit(&orderedJob{testJob: newTestJob("q-second", nil), key: "offer:1"})
+	assert.NoError(t, err)
+
+	pool := d.queues.pools["q"]
+	stopped := make(chan struct{})
+	go func() {
+		pool.Stop()
+		close(stopped)
+	}()
+	assert.Eventually(t, func() bool {
+		pool.goMu.Lock()
+		defer pool.goMu.Unlock()
+		return pool.stopping
+	}, time.Second, 5*time.Millisecond)
+
+	// Al liberarse la clave, el siguiente va a un pool que se está deteniendo
+	close(release)
+	waitStatus(t, func() JobStatus { return second.Status().Status }, JobFailed)
+	assert.Equal(t, ErrJobAbandoned.Error(), second.Status().Error)
+
+	close(unblock)
+	<-stopped
+	d.Stop()
+
+	entries, err := d.History(HistoryQuery{})
+	assert.NoError(t, err)
+	abandoned := 0
+	for _, entry := range entries {
+		if entry.JobName == "q-second" {
+			abandoned++
+			assert.Equal(t, ErrJobAbandoned.Error(), entry.Error)
+		}
+	}
+	assert.Equal(t, 1, abandoned)
+}
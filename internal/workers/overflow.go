@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// ErrJobDropped es el error de los jobs descartados por OverflowDropOldest
+var ErrJobDropped = errors.New("job dropped on queue overflow")
+
+// WithSpillQueue define la cola de desborde de OverflowSpillToDisk. Debería
+// ser una FileQueue sin capacidad (capacity 0) para que los jobs desviados
+// sobrevivan un reinicio; el pool la recupera y la cierra junto con la suya.
+func WithSpillQueue(queue Queue) PoolOption {
+	return func(c *poolConfig) {
+		c.spill = queue
+	}
+}
+
+// push encola el item aplicando la política de overflow del pool. Solo
+// bloquea si wait es true y la política es OverflowBlock, y como mucho hasta
+// que venza ctx.
+func (p *Pool) push(ctx context.Context, item *QueueItem, wait bool) error {
+	if wait && p.overflow == OverflowBlock {
+		pushCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(p.ctx, cancel)
+		defer stop()
+
+		err := p.jobQueue.Push(pushCtx, item)
+		if err != nil && p.ctx.Err() == nil && ctx.Err() != nil {
+			return fmt.Errorf("%w: %w", ErrQueueFull, ctx.Err())
+		}
+		return err
+	}
+
+	// Mientras haya jobs desbordados los nuevos van detrás de ellos
+	if p.overflow == OverflowSpillToDisk && p.spill != nil && p.spill.Len() > 0 {
+		return p.pushSpill(item)
+	}
+
+	err := p.tryPush(item)
+	if !errors.Is(err, ErrQueueFull) {
+		return err
+	}
+
+	switch p.overflow {
+	case OverflowDropOldest:
+		for errors.Is(err, ErrQueueFull) && p.dropOldest() {
+			err = p.tryPush(item)
+		}
+	case OverflowSpillToDisk:
+		if p.spill != nil {
+			err = p.pushSpill(item)
+		}
+	}
+	return err
+}
+
+// tryPush encola el item solo si hay lugar en la cola
+func (p *Pool) tryPush(item *QueueItem) error {
+	// Con un contexto ya cancelado Push vuelve de inmediato si la cola está llena
+	ctx, cancel := context.WithCancel(p.ctx)
+	cancel()
+	err := p.jobQueue.Push(ctx, item)
+	if errors.Is(err, context.Canceled) && p.ctx.Err() == nil {
+		return ErrQueueFull
+	}
+	return err
+}
+
+// pushSpill desvía el item a la cola de desborde
+func (p *Pool) pushSpill(item *QueueItem) error {
+	if err := p.spill.Push(p.ctx, item); err != nil {
+		return fmt.Errorf("failed to spill job: %w", err)
+	}
+	p.metrics.spilled.Add(1)
+	return nil
+}
+
+// dropOldest descarta el job encolado más antiguo. Devuelve false si la cola
+// ya no tiene jobs para descartar.
+func (p *Pool) dropOldest() bool {
+	item, removed := p.jobQueue.RemoveOldest()
+	if !removed {
+		return false
+	}
+
+	result := types.JobResult{
+		JobID:     item.ID,
+		JobName:   item.Job.Name(),
+		Error:     ErrJobDropped,
+		Timestamp: time.Now(),
+	}
+	p.jobs.finish(item.ID, result)
+	p.recordHistory(item, result)
+	p.metrics.dropped.Add(1)
+	log.Printf("Worker pool %s: dropped job %s (%s) to make room", p.name, item.Job.Name(), item.ID)
+
+	// Queda en el dead-letter store para poder reencolarlo más tarde
+	if p.deadLetters != nil {
+		entry := DeadLetter{
+			ID:         item.ID,
+			Job:        item.Job,
+			JobName:    result.JobName,
+			LastError:  result.Error,
+			EnqueuedAt: item.EnqueuedAt,
+			FailedAt:   result.Timestamp,
+		}
+		if err := p.deadLetters.Add(entry); err != nil {
+			log.Printf("Worker pool: failed to dead-letter job %s: %v", result.JobName, err)
+		}
+	}
+
+	p.release()
+	p.advanceKey(item)
+	return true
+}
+
+// removeSpilled quita un item de la cola de desborde
+func (p *Pool) removeSpilled(id string) (*QueueItem, bool) {
+	if p.spill == nil {
+		return nil, false
+	}
+	return p.spill.Remove(id)
+}
+
+// feedSpill devuelve los jobs desbordados a la cola a medida que se libera
+// lugar. Cada job se confirma en el spill recién cuando entró a la cola.
+func (p *Pool) feedSpill() {
+	for p.ctx.Err() == nil {
+		item, err := p.spill.Pop(p.ctx)
+		if err != nil {
+			return
+		}
+
+		if err := p.jobQueue.Push(p.ctx, item); err != nil {
+			// El pool se detiene: sigue persistido en el spill hasta el próximo arranque
+			p.abandon(item)
+			p.release()
+			return
+		}
+
+		if err := p.spill.Ack(item); err != nil {
+			log.Printf("Worker pool: failed to ack spilled job %s: %v", item.Job.Name(), err)
+		}
+	}
+}
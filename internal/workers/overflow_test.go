@@ -0,0 +1,141 @@
+package worker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// queuedTestJob es un serialJob que va a la cola "q"
+type queuedTestJob struct {
+	*serialJob
+}
+
+func (j *queuedTestJob) QueueName() string { return "q" }
+
+// newOverflowDispatcher crea un dispatcher pausado cuya cola "q" tiene lugar
+// para un solo job y ya está llena
+func newOverflowDispatcher(t *testing.T, cfg QueueConfig, run func(context.Context) error) (*Dispatcher, *JobHandle) {
+	cfg.Workers, cfg.Size = 1, 1
+	d := NewDispatcher(context.Background(), 1, 10, WithNamedQueue("q", cfg))
+	assert.NoError(t, d.Start())
+	t.Cleanup(d.Stop)
+	d.Pause()
+
+	handle, err := d.Submit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob("first", run)}})
+	assert.NoError(t, err)
+	return d, handle
+}
+
+func TestOverflowBlock(t *testing.T) {
+	d, _ := newOverflowDispatcher(t, QueueConfig{Overflow: OverflowBlock}, nil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := d.SubmitWithContext(ctx, &queuedTestJob{serialJob: &serialJob{testJob: newTestJob("second", nil)}})
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Al liberarse lugar el envío bloqueado entra
+	submitted := make(chan error, 1)
+	go func() {
+		_, err := d.Submit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob("third", nil)}})
+		submitted <- err
+	}()
+	d.Resume()
+	select {
+	case err := <-submitted:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("blocked submit did not resume")
+	}
+}
+
+func TestOverflowReject(t *testing.T) {
+	d, _ := newOverflowDispatcher(t, QueueConfig{Overflow: OverflowReject}, nil)
+
+	_, err := d.Submit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob("second", nil)}})
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	// TrySubmit rechaza con cualquier política
+	d, _ = newOverflowDispatcher(t, QueueConfig{Overflow: OverflowBlock}, nil)
+	_, err = d.TrySubmit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob("second", nil)}})
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestOverflowDropOldest(t *testing.T) {
+	d, first := newOverflowDispatcher(t, QueueConfig{Overflow: OverflowDropOldest}, nil)
+
+	second, err := d.Submit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob("second", nil)}})
+	assert.NoError(t, err)
+
+	result, err := first.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.ErrorIs(t, result.Error, ErrJobDropped)
+
+	if letters := d.DeadLetters(); assert.Len(t, letters, 1) {
+		assert.Equal(t, first.ID(), letters[0].ID)
+	}
+
+	d.Resume()
+	result, err = second.Wait(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestOverflowSpillToDisk(t *testing.T) {
+	spill, err := NewFileQueue(filepath.Join(t.TempDir(), "spill.log"), 0, newTestRegistry())
+	assert.NoError(t, err)
+
+	ran := make(chan string, 3)
+	run := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			ran <- name
+			return nil
+		}
+	}
+	d, first := newOverflowDispatcher(t, QueueConfig{
+		Overflow:    OverflowSpillToDisk,
+		PoolOptions: []PoolOption{WithSpillQueue(spill)},
+	}, run("first"))
+
+	handles := []*JobHandle{first}
+	for _, name := range []string{"second", "third"} {
+		handle, err := d.Submit(&queuedTestJob{serialJob: &serialJob{testJob: newTestJob(name, run(name))}})
+		assert.NoError(t, err)
+		handles = append(handles, handle)
+	}
+	assert.EqualValues(t, 2, d.queues.pools["q"].metrics.spilled.Load())
+
+	d.Resume()
+	for _, handle := range handles {
+		result, err := handle.Wait(context.Background())
+		assert.NoError(t, err)
+		assert.True(t, result.Success)
+	}
+
+	// Los desbordados vuelven a la cola en orden de llegada
+	assert.Equal(t, "first", <-ran)
+	assert.Equal(t, "second", <-ran)
+	assert.Equal(t, "third", <-ran)
+}
+
+func TestReplayDeadLetterHonoursCtxOnFullQueue(t *testing.T) {
+	d, _ := newOverflowDispatcher(t, QueueConfig{Overflow: OverflowBlock}, nil)
+
+	failed := &queuedTestJob{serialJob: &serialJob{testJob: newTestJob("failed", nil)}}
+	assert.NoError(t, d.deadLetters.Add(DeadLetter{ID: "failed-1", Job: failed, JobName: failed.Name()}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := d.ReplayDeadLetter(ctx, "failed-1")
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// Lo que no entró vuelve a la DLQ
+	_, err = d.DeadLetter("failed-1")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,366 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PauseStatus describe qué está pausado en el dispatcher
+type PauseStatus struct {
+	// Paused indica si está pausado el dispatcher completo
+	Paused bool `json:"paused"`
+	// Queues son las colas pausadas
+	Queues []string `json:"queues"`
+	// Jobs son los prefijos de nombre de job pausados
+	Jobs []string `json:"jobs"`
+}
+
+// pauseState guarda lo pausado en todas las colas de un dispatcher: el
+// dispatcher completo, colas por nombre y prefijos de nombre de job. changed
+// se cancela y se reemplaza en cada cambio para despertar a los pools.
+type pauseState struct {
+	mu      sync.Mutex
+	all     bool
+	queues  map[string]bool
+	jobs    map[string]bool
+	changed context.Context
+	signal  context.CancelFunc
+}
+
+func newPauseState() *pauseState {
+	changed, signal := context.WithCancel(context.Background())
+	return &pauseState{
+		queues:  make(map[string]bool),
+		jobs:    make(map[string]bool),
+		changed: changed,
+		signal:  signal,
+	}
+}
+
+// withPauseState comparte con el pool el estado de pausa del dispatcher
+func withPauseState(state *pauseState) PoolOption {
+	return func(c *poolConfig) {
+		c.pauses = state
+	}
+}
+
+// setAll pausa o reanuda todas las colas y devuelve false si ya estaban así
+func (s *pauseState) setAll(paused bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.all == paused {
+		return false
+	}
+	s.all = paused
+	s.broadcast()
+	return true
+}
+
+// setQueue pausa o reanuda una cola y devuelve false si ya estaba así
+func (s *pauseState) setQueue(name string, paused bool) bool {
+	return s.set(s.queues, name, paused)
+}
+
+// setJobs pausa o reanuda los jobs con el prefijo y devuelve false si ya estaban así
+func (s *pauseState) setJobs(prefix string, paused bool) bool {
+	return s.set(s.jobs, prefix, paused)
+}
+
+// set agrega o quita la clave del conjunto de pausados
+func (s *pauseState) set(keys map[string]bool, key string, paused bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if keys[key] == paused {
+		return false
+	}
+	if paused {
+		keys[key] = true
+	} else {
+		delete(keys, key)
+	}
+	s.broadcast()
+	return true
+}
+
+// queuePaused indica si la cola no debe entregar jobs
+func (s *pauseState) queuePaused(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.all || s.queues[name]
+}
+
+// jobPrefix devuelve el prefijo pausado que cubre el nombre del job, si hay uno
+func (s *pauseState) jobPrefix(name string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for prefix := range s.jobs {
+		if strings.HasPrefix(name, prefix) {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// jobPaused indica si el prefijo está pausado
+func (s *pauseState) jobPaused(prefix string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[prefix]
+}
+
+// changes devuelve un contexto que se cancela en el próximo cambio
+func (s *pauseState) changes() context.Context {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.changed
+}
+
+// status devuelve una copia de lo pausado
+func (s *pauseState) status() PauseStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return PauseStatus{
+		Paused: s.all,
+		Queues: slices.Sorted(maps.Keys(s.queues)),
+		Jobs:   slices.Sorted(maps.Keys(s.jobs)),
+	}
+}
+
+// broadcast despierta a todos los que esperan un cambio (requiere s.mu)
+func (s *pauseState) broadcast() {
+	s.signal()
+	s.changed, s.signal = context.WithCancel(context.Background())
+}
+
+// Pause deja de entregar jobs a los workers del pool. Los jobs siguen
+// encolándose y los que están en ejecución terminan normalmente.
+func (p *Pool) Pause() {
+	if p.pauses.setQueue(p.name, true) {
+		log.Printf("Worker pool %s paused", p.name)
+	}
+}
+
+// Resume vuelve a entregar los jobs del pool
+func (p *Pool) Resume() {
+	if p.pauses.setQueue(p.name, false) {
+		log.Printf("Worker pool %s resumed", p.name)
+	}
+}
+
+// Paused indica si el pool no está entregando jobs, por estar pausado él o
+// todo el dispatcher
+func (p *Pool) Paused() bool {
+	return p.pauses.queuePaused(p.name)
+}
+
+// heldJobs guarda, por prefijo, los jobs extraídos de la cola cuyo nombre
+// está pausado. Fuera de la cola no ocupan su capacidad, OverflowDropOldest no
+// los descarta y no se vuelven a revisar en cada extracción. No se confirman,
+// así una cola durable los vuelve a entregar si el proceso se reinicia.
+type heldJobs struct {
+	mu       sync.Mutex
+	byPrefix map[string][]*QueueItem
+	// ready son los reanudados que esperan un worker, por prioridad
+	ready *PriorityQueue
+}
+
+func newHeldJobs(agingInterval time.Duration) *heldJobs {
+	return &heldJobs{
+		byPrefix: make(map[string][]*QueueItem),
+		ready:    NewPriorityQueue(0, agingInterval),
+	}
+}
+
+// hold retiene el item hasta que se reanude el prefijo
+func (h *heldJobs) hold(prefix string, item *QueueItem) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.byPrefix[prefix] = append(h.byPrefix[prefix], item)
+}
+
+// release pasa a ready los jobs de los prefijos que ya no están pausados. Los
+// que también cubre otro prefijo pausado quedan retenidos bajo ese.
+func (h *heldJobs) release(pauses *pauseState) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for prefix, items := range h.byPrefix {
+		if pauses.jobPaused(prefix) {
+			continue
+		}
+		delete(h.byPrefix, prefix)
+		for _, item := range items {
+			if other, paused := pauses.jobPrefix(item.Job.Name()); paused {
+				h.byPrefix[other] = append(h.byPrefix[other], item)
+			} else {
+				h.ready.forcePush(item)
+			}
+		}
+	}
+}
+
+// next extrae el job reanudado más prioritario, o nil si no hay ninguno
+func (h *heldJobs) next() *QueueItem {
+	return h.ready.tryPop()
+}
+
+// remove quita un job retenido o reanudado por su ID
+func (h *heldJobs) remove(id string) (*QueueItem, bool) {
+	if item, ok := h.ready.Remove(id); ok {
+		return item, true
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for prefix, items := range h.byPrefix {
+		for i, item := range items {
+			if item.ID == id {
+				h.byPrefix[prefix] = slices.Delete(items, i, i+1)
+				return item, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// len devuelve cuántos jobs siguen retenidos por un prefijo pausado
+func (h *heldJobs) len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	n := 0
+	for _, items := range h.byPrefix {
+		n += len(items)
+	}
+	return n
+}
+
+// takeAll extrae todos los jobs retenidos y reanudados
+func (h *heldJobs) takeAll() []*QueueItem {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var items []*QueueItem
+	for _, held := range h.byPrefix {
+		items = append(items, held...)
+	}
+	clear(h.byPrefix)
+	for item := h.ready.tryPop(); item != nil; item = h.ready.tryPop() {
+		items = append(items, item)
+	}
+	return items
+}
+
+// popUnpaused extrae el próximo item de la cola, o devuelve nil si antes
+// cambia la pausa o llega wake (si no es cero)
+func (p *Pool) popUnpaused(changed context.Context, wake time.Time) (*QueueItem, error) {
+	ctx, cancel := context.WithCancel(p.ctx)
+	defer cancel()
+	if !wake.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, wake)
+		defer cancel()
+	}
+	stop := context.AfterFunc(changed, cancel)
+	defer stop()
+
+	// AfterFunc cancela en otra goroutine: el filtro evita extraer un job que
+	// llega justo después de pausar, antes de que se cancele ctx
+	eligible := func(*QueueItem) bool {
+		return changed.Err() == nil
+	}
+
+	item, err := p.jobQueue.PopFunc(ctx, eligible)
+	if err != nil && p.ctx.Err() == nil && (changed.Err() != nil || errors.Is(err, context.DeadlineExceeded)) {
+		return nil, nil
+	}
+	return item, err
+}
+
+// holdPaused retiene los jobs encolados que cubre un prefijo recién pausado,
+// así dejan de ocupar la cola sin esperar a que un worker los extraiga
+func (p *Pool) holdPaused(prefix string) {
+	items := p.jobQueue.TakeFunc(func(item *QueueItem) bool {
+		return strings.HasPrefix(item.Job.Name(), prefix)
+	})
+	for _, item := range items {
+		p.held.hold(prefix, item)
+	}
+}
+
+// Pause deja de entregar jobs en todas las colas. Los jobs siguen
+// encolándose y los que están en ejecución terminan normalmente.
+func (d *Dispatcher) Pause() {
+	if d.pauses.setAll(true) {
+		log.Println("Dispatcher paused")
+	}
+}
+
+// Resume vuelve a entregar jobs en todas las colas. Las colas y los jobs
+// pausados individualmente siguen pausados.
+func (d *Dispatcher) Resume() {
+	if d.pauses.setAll(false) {
+		log.Println("Dispatcher resumed")
+	}
+}
+
+// PauseQueue deja de entregar los jobs de una cola
+func (d *Dispatcher) PauseQueue(name string) error {
+	pool, ok := d.queues.pools[name]
+	if !ok {
+		return fmt.Errorf("unknown queue %q", name)
+	}
+	pool.Pause()
+	return nil
+}
+
+// ResumeQueue vuelve a entregar los jobs de una cola
+func (d *Dispatcher) ResumeQueue(name string) error {
+	pool, ok := d.queues.pools[name]
+	if !ok {
+		return fmt.Errorf("unknown queue %q", name)
+	}
+	pool.Resume()
+	return nil
+}
+
+// PauseJobs deja de ejecutar los jobs cuyo nombre empieza con prefix, en
+// cualquier cola. Salen de la cola y quedan retenidos sin ocupar workers ni
+// capacidad, así los demás jobs de la cola siguen encolándose y procesándose.
+// Devuelve false si ya estaba pausado.
+func (d *Dispatcher) PauseJobs(prefix string) bool {
+	if !d.pauses.setJobs(prefix, true) {
+		return false
+	}
+	for _, pool := range d.queues.all() {
+		pool.holdPaused(prefix)
+	}
+	log.Printf("Jobs %s* paused", prefix)
+	return true
+}
+
+// ResumeJobs vuelve a ejecutar los jobs con el prefijo. Devuelve false si no
+// estaba pausado.
+func (d *Dispatcher) ResumeJobs(prefix string) bool {
+	if !d.pauses.setJobs(prefix, false) {
+		return false
+	}
+	log.Printf("Jobs %s* resumed", prefix)
+	return true
+}
+
+// Pauses devuelve qué está pausado en el dispatcher
+func (d *Dispatcher) Pauses() PauseStatus {
+	return d.pauses.status()
+}
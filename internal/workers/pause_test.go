@@ -0,0 +1,131 @@
+package worker
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPausedJobsFreeQueueCapacity(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 2)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	assert.NoError(t, d.PauseQueue(DefaultQueueName))
+
+	for range 2 {
+		_, err := d.TrySubmit(newTestJob("paused-job", nil))
+		assert.NoError(t, err)
+	}
+	_, err := d.TrySubmit(newTestJob("other-job", nil))
+	assert.ErrorIs(t, err, ErrQueueFull)
+
+	assert.True(t, d.PauseJobs("paused-"))
+	assert.False(t, d.PauseJobs("paused-"))
+	for range 2 {
+		_, err := d.TrySubmit(newTestJob("other-job", nil))
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 2, d.workerPool.Pending())
+	assert.Equal(t, 2, d.Stats().Queues[DefaultQueueName].PausedJobs)
+}
+
+func TestPausedJobsDoNotBlockOthers(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	d.PauseJobs("paused-")
+
+	paused, err := d.Submit(newTestJob("paused-job", nil))
+	assert.NoError(t, err)
+	other, err := d.Submit(newTestJob("other-job", nil))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	_, err = other.Wait(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, JobQueued, paused.Status().Status)
+
+	assert.True(t, d.ResumeJobs("paused-"))
+	result, err := paused.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestResumedJobsKeepPriorityOrder(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10, WithPoolOptions(WithAgingInterval(0)))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	d.PauseJobs("job-")
+
+	var (
+		mu    sync.Mutex
+		order []int
+	)
+	handles := make([]*JobHandle, 0, 5)
+	for _, priority := range []int{1, 5, 3, 4, 2} {
+		job := newTestJob("job-", func(context.Context) error {
+			mu.Lock()
+			order = append(order, priority)
+			mu.Unlock()
+			return nil
+		})
+		job.priority = priority
+		handle, err := d.Submit(job)
+		assert.NoError(t, err)
+		handles = append(handles, handle)
+	}
+
+	d.ResumeJobs("job-")
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	for _, handle := range handles {
+		_, err := handle.Wait(ctx)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{5, 4, 3, 2, 1}, order)
+}
+
+func TestPauseAndResumeQueue(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	assert.NoError(t, d.PauseQueue(DefaultQueueName))
+
+	handle, err := d.Submit(newTestJob("job", nil))
+	assert.NoError(t, err)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, JobQueued, handle.Status().Status)
+	assert.Equal(t, []string{DefaultQueueName}, d.Pauses().Queues)
+	assert.Empty(t, d.Pauses().Jobs)
+
+	assert.NoError(t, d.ResumeQueue(DefaultQueueName))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := handle.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+}
+
+func TestCancelPausedJob(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	d.PauseJobs("paused-")
+
+	handle, err := d.Submit(newTestJob("paused-job", nil))
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return d.Stats().Queues[DefaultQueueName].PausedJobs == 1
+	}, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, handle.Cancel())
+	assert.Equal(t, JobCancelled, handle.Status().Status)
+	assert.Zero(t, d.Stats().Queues[DefaultQueueName].PausedJobs)
+}
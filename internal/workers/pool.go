@@ -2,35 +2,253 @@ package worker
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
 )
 
+// DefaultJobTimeout es el tiempo máximo de ejecución de un job que no declara el suyo
+const DefaultJobTimeout = 5 * time.Minute
+
 // Pool maneja un conjunto de workers para procesar jobs
 type Pool struct {
+	name       string
 	workers    []*Worker
-	workerPool chan chan Job
+	workerPool chan chan *QueueItem
+	minWorkers int
 	maxWorkers int
-	jobQueue   chan Job
-	wg         sync.WaitGroup
-	ctx        context.Context
-	cancel     context.CancelFunc
-	mu         sync.Mutex
-	started    bool
+	nextID     int
+	jobQueue   Queue
+	overflow   OverflowPolicy
+	// spill recibe los jobs que no entran en jobQueue con OverflowSpillToDisk
+	spill Queue
+	// retryPolicy se aplica a los jobs que no declaran la suya
+	retryPolicy types.RetryPolicy
+	// deadLetters recibe los jobs que fallaron definitivamente (puede ser nil)
+	deadLetters DeadLetterStore
+	// history recibe el resultado final de cada job (puede ser nil)
+	history HistoryStore
+	// jobTimeout se aplica a los jobs que no implementan types.TimeoutJob
+	jobTimeout time.Duration
+	// rateLimits limita la tasa de ejecución por recurso o tipo de job
+	rateLimits *RateLimiter
+	// sharedMiddleware, middleware y jobMiddleware envuelven cada intento de ejecución
+	sharedMiddleware []Middleware
+	middleware       []Middleware
+	jobMiddleware    []jobMiddleware
+	// propagators llevan valores del contexto de quien encola al de ejecución
+	propagators []ContextPropagator
+	// jobs rastrea el estado de cada job desde que se encola
+	jobs *jobTracker
+	// metrics agrega los resultados y latencias de todos los workers
+	metrics *poolMetrics
+	// ordering retiene los jobs que esperan a otro con su misma clave de orden
+	ordering *keySequencer
+	// pauses es lo pausado en el pool o el dispatcher
+	pauses *pauseState
+	// held retiene los jobs extraídos de la cola con el nombre pausado
+	held *heldJobs
+	// throttled retiene los jobs extraídos de la cola sin token de su límite de tasa
+	throttled *throttledJobs
+	// running son los jobs en ejecución, vigilados por el watchdog
+	running         map[string]*runningJob
+	runMu           sync.Mutex
+	stuckDetections atomic.Int64
+	// outstanding cuenta los items encolados o en ejecución; Drain espera a que llegue a cero
+	outstanding atomic.Int64
+	idle        chan struct{}
+	draining    atomic.Bool
+	abandoned   []*QueueItem
+	abandonMu   sync.Mutex
+	wg          sync.WaitGroup
+	// stopping se activa cuando Stop empieza a esperar a wg: desde ahí nadie
+	// más puede lanzar goroutines en el pool (protegido por goMu)
+	stopping bool
+	goMu     sync.Mutex
+	ctx      context.Context
+	cancel   context.CancelFunc
+	mu       sync.Mutex
+	started  atomic.Bool
+}
+
+// PoolOption configura parámetros opcionales del pool
+type PoolOption func(*poolConfig)
+
+type poolConfig struct {
+	name          string
+	overflow      OverflowPolicy
+	spill         Queue
+	agingInterval time.Duration
+	queue         Queue
+	minWorkers    int
+	retryPolicy   types.RetryPolicy
+	deadLetters   DeadLetterStore
+	history       HistoryStore
+	jobTimeout    time.Duration
+	rateLimits    *RateLimiter
+	jobHistory    int
+	// sharedMiddleware y jobMiddleware vienen del dispatcher; middleware es propio del pool
+	sharedMiddleware []Middleware
+	middleware       []Middleware
+	jobMiddleware    []jobMiddleware
+	propagators      []ContextPropagator
+	pauses           *pauseState
+	ordering         *keySequencer
+	// idempotencyWindow es cuánto se recuerda la clave de un job exitoso
+	idempotencyWindow time.Duration
+}
+
+// WithAgingInterval define cada cuánto tiempo de espera un job gana un punto
+// de prioridad. Cero desactiva el envejecimiento (prioridad estricta).
+func WithAgingInterval(interval time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.agingInterval = interval
+	}
+}
+
+// WithQueue reemplaza la cola en memoria por defecto (por ejemplo por una
+// FileQueue durable). El tamaño de cola del pool se ignora en ese caso.
+func WithQueue(queue Queue) PoolOption {
+	return func(c *poolConfig) {
+		c.queue = queue
+	}
+}
+
+// WithMinWorkers permite que el pool arranque con menos workers que el máximo
+// y escale entre ambos límites con AddWorkers/RemoveWorkers
+func WithMinWorkers(minWorkers int) PoolOption {
+	return func(c *poolConfig) {
+		c.minWorkers = minWorkers
+	}
+}
+
+// WithDefaultRetryPolicy define la política de reintentos para los jobs que
+// no implementan types.RetryableJob (por defecto no se reintenta)
+func WithDefaultRetryPolicy(policy types.RetryPolicy) PoolOption {
+	return func(c *poolConfig) {
+		c.retryPolicy = policy
+	}
+}
+
+// withDeadLetterStore define dónde se guardan los jobs que agotaron sus intentos
+func withDeadLetterStore(store DeadLetterStore) PoolOption {
+	return func(c *poolConfig) {
+		c.deadLetters = store
+	}
+}
+
+// withRateLimiter comparte el limitador de tasa del dispatcher con el pool
+func withRateLimiter(limiter *RateLimiter) PoolOption {
+	return func(c *poolConfig) {
+		c.rateLimits = limiter
+	}
 }
 
-// NewWorkerPool crea un nuevo pool de workers
-func NewWorkerPool(maxWorkers int, jobQueueSize int) *Pool {
+// WithJobTimeout define el tiempo máximo de ejecución para los jobs que no
+// implementan types.TimeoutJob (por defecto DefaultJobTimeout)
+func WithJobTimeout(timeout time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.jobTimeout = timeout
+	}
+}
+
+// WithJobHistory define cuántos jobs terminados se conservan para consultar
+// su estado (por defecto DefaultJobHistory)
+func WithJobHistory(size int) PoolOption {
+	return func(c *poolConfig) {
+		c.jobHistory = size
+	}
+}
+
+// WithIdempotencyWindow define durante cuánto tiempo, después de terminar con
+// éxito, un job con clave de idempotencia sigue deduplicando envíos con la
+// misma clave. Por defecto solo se deduplica mientras el job está en vuelo.
+func WithIdempotencyWindow(window time.Duration) PoolOption {
+	return func(c *poolConfig) {
+		c.idempotencyWindow = window
+	}
+}
+
+// NewWorkerPool crea un nuevo pool de workers. Un jobQueueSize <= 0 crea una
+// cola sin límite (ya no un traspaso sincrónico): Submit nunca bloquea y las
+// políticas de overflow no se aplican.
+func NewWorkerPool(maxWorkers int, jobQueueSize int, opts ...PoolOption) *Pool {
 	ctx, cancel := context.WithCancel(context.Background())
 
+	cfg := poolConfig{
+		name:          DefaultQueueName,
+		agingInterval: DefaultAgingInterval,
+		minWorkers:    maxWorkers,
+		retryPolicy:   types.NoRetry,
+		jobTimeout:    DefaultJobTimeout,
+		jobHistory:    DefaultJobHistory,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	if cfg.minWorkers < 1 || cfg.minWorkers > maxWorkers {
+		cfg.minWorkers = maxWorkers
+	}
+
+	if cfg.rateLimits == nil {
+		cfg.rateLimits = NewRateLimiter()
+	}
+
+	if cfg.pauses == nil {
+		cfg.pauses = newPauseState()
+	}
+
+	if cfg.ordering == nil {
+		cfg.ordering = newKeySequencer()
+	}
+
+	if cfg.queue == nil && jobQueueSize <= 0 && cfg.overflow != OverflowBlock {
+		log.Printf("Worker pool %s: %s overflow policy has no effect on an unbounded queue", cfg.name, cfg.overflow)
+	}
+
+	if cfg.overflow == OverflowSpillToDisk && cfg.spill == nil {
+		log.Printf("Worker pool %s: spill policy without a spill queue, full queue submissions will be rejected", cfg.name)
+	}
+
+	queue := cfg.queue
+	if queue == nil {
+		queue = NewPriorityQueue(jobQueueSize, cfg.agingInterval)
+	}
+
 	return &Pool{
-		maxWorkers: maxWorkers,
-		workerPool: make(chan chan Job, maxWorkers),
-		jobQueue:   make(chan Job, jobQueueSize),
-		workers:    make([]*Worker, 0, maxWorkers),
-		ctx:        ctx,
-		cancel:     cancel,
+		name:             cfg.name,
+		overflow:         cfg.overflow,
+		spill:            cfg.spill,
+		minWorkers:       cfg.minWorkers,
+		maxWorkers:       maxWorkers,
+		retryPolicy:      cfg.retryPolicy,
+		deadLetters:      cfg.deadLetters,
+		history:          cfg.history,
+		jobTimeout:       cfg.jobTimeout,
+		rateLimits:       cfg.rateLimits,
+		sharedMiddleware: cfg.sharedMiddleware,
+		middleware:       cfg.middleware,
+		jobMiddleware:    cfg.jobMiddleware,
+		propagators:      cfg.propagators,
+		jobs:             newJobTracker(cfg.jobHistory, cfg.idempotencyWindow),
+		metrics:          newPoolMetrics(),
+		ordering:         cfg.ordering,
+		pauses:           cfg.pauses,
+		held:             newHeldJobs(cfg.agingInterval),
+		throttled:        newThrottledJobs(),
+		running:          make(map[string]*runningJob),
+		idle:             make(chan struct{}, 1),
+		workerPool:       make(chan chan *QueueItem, maxWorkers),
+		jobQueue:         queue,
+		workers:          make([]*Worker, 0, maxWorkers),
+		ctx:              ctx,
+		cancel:           cancel,
 	}
 }
 
@@ -39,96 +257,476 @@ func (p *Pool) Start() error {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if p.started {
+	if p.started.Load() {
 		return fmt.Errorf("worker pool already started")
 	}
 
+	// Rehidratar los jobs pendientes si la cola es durable
+	if durable, ok := p.jobQueue.(DurableQueue); ok {
+		recovered, err := durable.Recover()
+		if err != nil {
+			return fmt.Errorf("failed to recover queue: %w", err)
+		}
+		p.adopt(recovered)
+		p.resequence(recovered)
+		if len(recovered) > 0 {
+			log.Printf("Worker pool recovered %d pending jobs", len(recovered))
+		}
+	}
+
+	// Lo desbordado antes de un reinicio vuelve a la cola por el spill
+	if durable, ok := p.spill.(DurableQueue); ok {
+		recovered, err := durable.Recover()
+		if err != nil {
+			return fmt.Errorf("failed to recover spill queue: %w", err)
+		}
+		p.adopt(recovered)
+		if len(recovered) > 0 {
+			log.Printf("Worker pool recovered %d spilled jobs", len(recovered))
+		}
+	}
+
 	// Inicializar y arrancar los workers usando waitgroup.Go
-	for i := 0; i < p.maxWorkers; i++ {
-		worker := NewWorker(i, p.workerPool, p.ctx)
-		p.workers = append(p.workers, worker)
-		p.wg.Go(worker.run)
+	for i := 0; i < p.minWorkers; i++ {
+		p.spawnWorker()
 	}
 
-	// Iniciar el distribuidor de trabajos usando waitgroup.Go
+	// Iniciar el distribuidor de trabajos y el watchdog de heartbeats
 	p.wg.Go(p.dispatch)
+	p.wg.Go(p.watchdog)
+	if p.spill != nil {
+		p.wg.Go(p.feedSpill)
+	}
 
-	p.started = true
-	log.Printf("Worker pool started with %d workers", p.maxWorkers)
+	p.started.Store(true)
+	log.Printf("Worker pool %s started with %d workers", p.name, len(p.workers))
 	return nil
 }
 
-// dispatch distribuye los trabajos entre los workers disponibles
+// adopt rastrea los items recuperados de una cola durable, así se pueden
+// consultar y cancelar como los encolados con Submit
+func (p *Pool) adopt(items []*QueueItem) {
+	for _, item := range items {
+		p.jobs.adopt(item)
+	}
+	p.outstanding.Add(int64(len(items)))
+}
+
+// resequence vuelve a retener, en el orden en que se persistieron, los items
+// recuperados de la cola que esperaban su turno por clave de orden antes del
+// reinicio. Salen de la cola sin confirmarse y siguen persistidos.
+func (p *Pool) resequence(items []*QueueItem) {
+	held := make(map[string]bool)
+	for _, item := range items {
+		if admitted, _ := p.ordering.admit(p, item, nil); !admitted {
+			held[item.ID] = true
+		}
+	}
+	if len(held) > 0 {
+		p.jobQueue.TakeFunc(func(item *QueueItem) bool {
+			return held[item.ID]
+		})
+	}
+}
+
+// spawnWorker crea y arranca un nuevo worker (requiere p.mu)
+func (p *Pool) spawnWorker() {
+	worker := NewWorker(p.nextID, p)
+	p.nextID++
+	p.workers = append(p.workers, worker)
+	p.wg.Go(worker.run)
+}
+
+// AddWorkers agrega hasta n workers sin superar el máximo y devuelve cuántos agregó
+func (p *Pool) AddWorkers(n int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.started.Load() {
+		return 0
+	}
+
+	added := 0
+	for added < n && len(p.workers) < p.maxWorkers {
+		p.spawnWorker()
+		added++
+	}
+	return added
+}
+
+// RemoveWorkers retira hasta n workers ociosos sin bajar del mínimo y devuelve
+// cuántos retiró. Un worker ocioso es el que está registrado en workerPool
+// esperando trabajo: al sacarlo del canal ya no puede recibir jobs, y se le
+// envía un item nil para que termine.
+func (p *Pool) RemoveWorkers(n int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.started.Load() {
+		return 0
+	}
+
+	removed := 0
+	for removed < n && len(p.workers) > p.minWorkers {
+		var jobChannel chan *QueueItem
+		select {
+		case jobChannel = <-p.workerPool:
+		default:
+			// No hay workers ociosos
+			return removed
+		}
+
+		select {
+		case jobChannel <- nil:
+		case <-p.ctx.Done():
+			return removed
+		}
+
+		for i, worker := range p.workers {
+			if worker.jobChannel == jobChannel {
+				p.workers = append(p.workers[:i], p.workers[i+1:]...)
+				break
+			}
+		}
+		removed++
+	}
+	return removed
+}
+
+// dispatch distribuye los trabajos entre los workers disponibles.
+// Primero espera un worker libre y recién entonces extrae el job de la cola,
+// así siempre se entrega el más prioritario en ese momento.
 func (p *Pool) dispatch() {
 	for {
+		var jobChannel chan *QueueItem
+
+		// Esperar un worker disponible del pool
 		select {
+		case jobChannel = <-p.workerPool:
 		case <-p.ctx.Done():
 			log.Println("Dispatcher shutting down")
 			return
+		}
 
-		case job := <-p.jobQueue:
-			// Esperar un worker disponible del pool
-			select {
-			case jobChannel := <-p.workerPool:
-				// Enviar el trabajo al worker
-				select {
-				case jobChannel <- job:
-					// Trabajo enviado al worker
-				case <-p.ctx.Done():
-					return
-				}
+		// Espera mientras el pool esté pausado y saltea los jobs pausados
+		item, err := p.next()
+		if err != nil {
+			log.Println("Dispatcher shutting down")
+			return
+		}
 
+		// Enviar el trabajo al worker
+		select {
+		case jobChannel <- item:
+			// Trabajo enviado al worker
+		case <-p.ctx.Done():
+			p.abandon(item)
+			p.release()
+			return
+		}
+	}
+}
+
+// next devuelve el próximo item a entregar a un worker, ya con el token de su
+// límite de tasa. Mientras el pool está pausado no extrae nada de la cola. Los
+// jobs con el nombre pausado que salen de la cola quedan retenidos hasta que
+// se reanuden, y entonces se entregan antes que los que siguen en la cola; los
+// que no obtienen token esperan aparte hasta que haya uno.
+func (p *Pool) next() (*QueueItem, error) {
+	for {
+		changed := p.pauses.changes()
+
+		if p.Paused() {
+			select {
+			case <-changed.Done():
+				continue
 			case <-p.ctx.Done():
-				return
+				return nil, p.ctx.Err()
+			}
+		}
+
+		p.held.release(p.pauses)
+		if item := p.held.next(); item != nil {
+			if p.admitRate(item) {
+				return item, nil
+			}
+			continue
+		}
+
+		// Los que esperaban un token ya lo tomaron al salir de throttled
+		item, wake := p.throttled.next(p.rateLimits)
+		if item != nil {
+			if p.holdIfPaused(item) {
+				continue
 			}
+			return item, nil
+		}
+
+		item, err := p.popUnpaused(changed, wake)
+		if err != nil {
+			return nil, err
+		}
+		if item == nil {
+			// Cambió la pausa o venció la espera de un job sin token
+			continue
+		}
+
+		if p.holdIfPaused(item) || !p.admitRate(item) {
+			continue
 		}
+		return item, nil
 	}
 }
 
-// Submit encola un nuevo trabajo para ser procesado
+// holdIfPaused retiene el item si su nombre está pausado
+func (p *Pool) holdIfPaused(item *QueueItem) bool {
+	prefix, paused := p.pauses.jobPrefix(item.Job.Name())
+	if paused {
+		p.held.hold(prefix, item)
+	}
+	return paused
+}
+
+// admitRate toma un token del límite de tasa del item. Si no hay, o si hay
+// otros jobs esperando uno del mismo límite, lo deja esperando detrás de ellos.
+func (p *Pool) admitRate(item *QueueItem) bool {
+	bucket := p.rateLimits.For(item.Job)
+	if bucket == nil {
+		return true
+	}
+	if !p.throttled.waiting(bucket) {
+		if _, ok := bucket.take(); ok {
+			return true
+		}
+	}
+	p.throttled.add(bucket, item)
+	return false
+}
+
+// Submit encola un nuevo trabajo para ser procesado. Con la cola llena se
+// comporta según la política de overflow del pool.
 func (p *Pool) Submit(job Job) error {
-	if !p.started {
-		return fmt.Errorf("worker pool not started")
+	_, err := p.submit(context.Background(), job, true)
+	return err
+}
+
+// TrySubmit encola el trabajo sin bloquear: con la cola llena y la política
+// OverflowBlock devuelve ErrQueueFull en lugar de esperar
+func (p *Pool) TrySubmit(job Job) error {
+	_, err := p.submit(context.Background(), job, false)
+	return err
+}
+
+// SubmitWithContext encola el trabajo esperando lugar en la cola como mucho
+// hasta que venza ctx. En ese caso devuelve un error que envuelve ErrQueueFull
+// y el de ctx.
+func (p *Pool) SubmitWithContext(ctx context.Context, job Job) error {
+	_, err := p.submit(ctx, job, true)
+	return err
+}
+
+// submit encola el trabajo y devuelve un handle para rastrearlo. Solo espera
+// lugar en la cola si wait es true y la política es OverflowBlock.
+func (p *Pool) submit(ctx context.Context, job Job, wait bool) (*JobHandle, error) {
+	if p.draining.Load() {
+		return nil, ErrPoolDraining
+	}
+	if !p.started.Load() {
+		return nil, fmt.Errorf("worker pool not started")
 	}
 
+	// Se rastrea antes de encolar para que el worker siempre lo encuentre
+	item := newQueueItem(job)
+	p.captureContext(ctx, item)
+	tracked, duplicate := p.jobs.track(item)
+	if duplicate {
+		p.metrics.deduplicated.Add(1)
+		log.Printf("Worker pool: job %s coalesced with %s (same idempotency key)", job.Name(), tracked.info.ID)
+		if responder, ok := job.(types.JobWithResponse); ok && responder.ResponseChannel() != nil {
+			go p.forwardResult(tracked, responder)
+		}
+		return &JobHandle{pool: p, job: tracked}, nil
+	}
+	p.outstanding.Add(1)
+	p.metrics.submitted.Add(1)
+
+	// Si hay otro job activo con la misma clave de orden, espera su turno
+	admitted, err := p.admit(item)
+	if err != nil {
+		p.jobs.forget(item.ID)
+		p.release()
+		return nil, fmt.Errorf("failed to hold job %s for its ordering key: %w", job.Name(), err)
+	}
+	if !admitted {
+		return &JobHandle{pool: p, job: tracked}, nil
+	}
+
+	if err := p.push(ctx, item, wait); err != nil {
+		p.jobs.forget(item.ID)
+		p.release()
+		p.advanceKey(item)
+		if p.ctx.Err() != nil || err == ErrQueueClosed {
+			return nil, fmt.Errorf("worker pool is shutting down")
+		}
+		if errors.Is(err, ErrQueueFull) {
+			p.metrics.rejected.Add(1)
+		}
+		return nil, fmt.Errorf("failed to enqueue job %s: %w", job.Name(), err)
+	}
+	return &JobHandle{pool: p, job: tracked}, nil
+}
+
+// forwardResult entrega el resultado del job existente al canal de un
+// duplicado, así quien espera una respuesta por cada envío la recibe igual
+func (p *Pool) forwardResult(existing *trackedJob, responder types.JobWithResponse) {
 	select {
-	case p.jobQueue <- job:
-		return nil
+	case <-existing.done:
 	case <-p.ctx.Done():
-		return fmt.Errorf("worker pool is shutting down")
+		return
 	}
+
+	existing.mu.Lock()
+	result := existing.result
+	existing.mu.Unlock()
+
+	p.respond(responder, result, p.timeout(responder))
 }
 
-// Stop detiene el pool y todos sus workers de manera ordenada
+// timeout devuelve el tiempo máximo de ejecución del job: el suyo si
+// implementa types.TimeoutJob, o el del pool
+func (p *Pool) timeout(job Job) time.Duration {
+	if timeoutJob, ok := job.(types.TimeoutJob); ok && timeoutJob.Timeout() > 0 {
+		return timeoutJob.Timeout()
+	}
+	return p.jobTimeout
+}
+
+// finish confirma a la cola que el item terminó de procesarse y envía los
+// jobs fallidos al dead-letter store. Si el job falló porque el pool se está
+// deteniendo no se confirma, así una cola durable lo vuelve a entregar en el
+// próximo arranque, y queda como abandonado para el reporte de Drain. Los
+// jobs cancelados se confirman pero no son dead letters.
+func (p *Pool) finish(item *QueueItem, result types.JobResult) {
+	defer p.release()
+	defer p.advanceKey(item)
+
+	cancelled := errors.Is(result.Error, ErrJobCancelled)
+	if !result.Success && !cancelled && p.ctx.Err() != nil {
+		p.abandon(item)
+		return
+	}
+
+	p.jobs.finish(item.ID, result)
+	p.recordHistory(item, result)
+
+	if err := p.jobQueue.Ack(item); err != nil {
+		log.Printf("Worker pool: failed to ack job %s: %v", item.Job.Name(), err)
+	}
+
+	if !result.Success && !cancelled && p.deadLetters != nil {
+		entry := DeadLetter{
+			ID:         item.ID,
+			Job:        item.Job,
+			JobName:    result.JobName,
+			LastError:  result.Error,
+			Attempts:   result.Attempts,
+			EnqueuedAt: item.EnqueuedAt,
+			FailedAt:   result.Timestamp,
+		}
+		if err := p.deadLetters.Add(entry); err != nil {
+			log.Printf("Worker pool: failed to dead-letter job %s: %v", result.JobName, err)
+		}
+	}
+}
+
+// goUnlessStopping lanza fn en wg salvo que el pool se esté deteniendo, en
+// cuyo caso devuelve false. Es para goroutines que lanza otro pool (como
+// advanceKey), que no puede saber si este ya empezó a esperar a wg.
+func (p *Pool) goUnlessStopping(fn func()) bool {
+	p.goMu.Lock()
+	defer p.goMu.Unlock()
+
+	if p.stopping {
+		return false
+	}
+	p.wg.Go(fn)
+	return true
+}
+
+// Stop detiene el pool y todos sus workers de manera ordenada. Los jobs que
+// quedan sin procesar terminan con ErrJobAbandoned, también en el historial.
 func (p *Pool) Stop() {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	if !p.started {
+	if !p.started.Load() {
 		return
 	}
 
-	log.Println("Stopping worker pool...")
+	log.Printf("Stopping worker pool %s...", p.name)
 	// Cancelar el contexto para notificar a todos los workers
 	p.cancel()
 
-	// Esperar a que todos los workers terminen
+	// Esperar a que todos los workers terminen, sin que otro pool pueda
+	// agregar goroutines mientras tanto
+	p.goMu.Lock()
+	p.stopping = true
+	p.goMu.Unlock()
 	p.wg.Wait()
 
 	// Limpiar recursos
-	close(p.jobQueue)
+	if err := p.jobQueue.Close(); err != nil {
+		log.Printf("Worker pool: failed to close queue: %v", err)
+	}
+	if p.spill != nil {
+		if err := p.spill.Close(); err != nil {
+			log.Printf("Worker pool: failed to close spill queue: %v", err)
+		}
+	}
 	close(p.workerPool)
+	p.settleAbandoned()
 
-	p.started = false
-	log.Println("Worker pool stopped")
+	p.workers = p.workers[:0]
+	p.started.Store(false)
+	log.Printf("Worker pool %s stopped", p.name)
 }
 
-// Size devuelve el número de workers en el pool
+// Size devuelve el número actual de workers en el pool
 func (p *Pool) Size() int {
-	return p.maxWorkers
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.started.Load() {
+		return p.minWorkers
+	}
+	return len(p.workers)
+}
+
+// Idle devuelve el número aproximado de workers esperando trabajo
+func (p *Pool) Idle() int {
+	return len(p.workerPool)
 }
 
-// Pending devuelve el número aproximado de trabajos pendientes
+// Bounds devuelve los límites mínimo y máximo de workers
+func (p *Pool) Bounds() (int, int) {
+	return p.minWorkers, p.maxWorkers
+}
+
+// Pending devuelve el número aproximado de trabajos pendientes, incluidos los
+// que esperan a otro job con su misma clave de orden. Los retenidos por un
+// prefijo pausado o por su límite de tasa no cuentan: no son carga que más
+// workers puedan atender.
 func (p *Pool) Pending() int {
-	return len(p.jobQueue)
+	return p.runnable() + p.ordering.len(p)
+}
+
+// runnable es la parte de Pending que más workers podrían atender ya: no
+// cuenta los jobs que esperan a otro con su misma clave de orden, porque esos
+// se ejecutan de a uno sin importar el tamaño del pool
+func (p *Pool) runnable() int {
+	pending := p.jobQueue.Len() + p.held.ready.Len()
+	if p.spill != nil {
+		pending += p.spill.Len()
+	}
+	return pending
 }
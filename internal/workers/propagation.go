@@ -0,0 +1,99 @@
+package worker
+
+import (
+	"context"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// ContextPropagator lleva valores del contexto de quien encola un job (tenant,
+// usuario, span de tracing) al contexto en que se ejecuta
+type ContextPropagator interface {
+	// Capture toma los valores de ctx al encolar el job
+	Capture(ctx context.Context) any
+	// Restore vuelve a poner en ctx lo capturado, al ejecutar el job
+	Restore(ctx context.Context, captured any) context.Context
+}
+
+// WithContextPropagation define qué valores del contexto de SubmitWithContext
+// o EnqueueJobContext llegan al contexto de ejecución de los jobs de todas las
+// colas. El ID de types.WithRequestID se propaga siempre.
+func WithContextPropagation(propagators ...ContextPropagator) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		c.propagators = append(c.propagators, propagators...)
+	}
+}
+
+// withPropagators comparte con el pool los propagadores del dispatcher
+func withPropagators(propagators []ContextPropagator) PoolOption {
+	return func(c *poolConfig) {
+		c.propagators = propagators
+	}
+}
+
+// keyPropagator copia los valores de un conjunto de claves de contexto
+type keyPropagator struct {
+	keys []any
+}
+
+// ContextKeys propaga los valores guardados en el contexto con las claves indicadas
+func ContextKeys(keys ...any) ContextPropagator {
+	return keyPropagator{keys: keys}
+}
+
+// Capture implementa la interfaz ContextPropagator
+func (p keyPropagator) Capture(ctx context.Context) any {
+	values := make([]any, len(p.keys))
+	for i, key := range p.keys {
+		values[i] = ctx.Value(key)
+	}
+	return values
+}
+
+// Restore implementa la interfaz ContextPropagator
+func (p keyPropagator) Restore(ctx context.Context, captured any) context.Context {
+	values, _ := captured.([]any)
+	for i, value := range values {
+		if value != nil && i < len(p.keys) {
+			ctx = context.WithValue(ctx, p.keys[i], value)
+		}
+	}
+	return ctx
+}
+
+// captureContext guarda en el item el ID de request y los valores a propagar
+func (p *Pool) captureContext(ctx context.Context, item *QueueItem) {
+	item.RequestID = types.RequestID(ctx)
+	if len(p.propagators) == 0 {
+		return
+	}
+
+	item.captured = make([]any, len(p.propagators))
+	for i, propagator := range p.propagators {
+		item.captured[i] = propagator.Capture(ctx)
+	}
+}
+
+// restoreContext restaura en el contexto de ejecución lo capturado al encolar.
+// Los jobs recuperados de una cola durable solo conservan el ID de request.
+func (p *Pool) restoreContext(ctx context.Context, item *QueueItem) context.Context {
+	if item.RequestID != "" {
+		ctx = types.WithRequestID(ctx, item.RequestID)
+	}
+	if len(item.captured) != len(p.propagators) {
+		return ctx
+	}
+
+	for i, propagator := range p.propagators {
+		ctx = propagator.Restore(ctx, item.captured[i])
+	}
+	return ctx
+}
+
+// jobLabel identifica al job en los logs, con el ID de la request que lo originó
+func jobLabel(item *QueueItem) string {
+	if item.RequestID == "" {
+		return item.Job.Name()
+	}
+	return item.Job.Name() + " [request_id=" + item.RequestID + "]"
+}
@@ -0,0 +1,60 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tenantKey struct{}
+
+// seenContext es lo que un job encontró en su contexto de ejecución
+type seenContext struct {
+	tenant    any
+	requestID string
+}
+
+// contextJob reporta por seen los valores de su contexto de ejecución
+func contextJob(name string, seen chan<- seenContext) *testJob {
+	return newTestJob(name, func(ctx context.Context) error {
+		seen <- seenContext{tenant: ctx.Value(tenantKey{}), requestID: types.RequestID(ctx)}
+		return nil
+	})
+}
+
+func TestContextPropagation(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10, WithContextPropagation(ContextKeys(tenantKey{})))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	seen := make(chan seenContext, 1)
+
+	ctx := types.WithRequestID(context.WithValue(context.Background(), tenantKey{}, "acme"), "req-42")
+	propagated, err := d.SubmitWithContext(ctx, contextJob("propagated", seen))
+	assert.NoError(t, err)
+	assert.Equal(t, seenContext{tenant: "acme", requestID: "req-42"}, <-seen)
+
+	_, err = d.Submit(contextJob("plain", seen))
+	assert.NoError(t, err)
+	assert.Equal(t, seenContext{}, <-seen)
+
+	// El ID de request queda en el estado y en el historial del job
+	waitStatus(t, func() JobStatus { return propagated.Status().Status }, JobSucceeded)
+	assert.Equal(t, "req-42", propagated.Status().RequestID)
+
+	var entry HistoryEntry
+	assert.Eventually(t, func() bool {
+		entries, err := d.History(HistoryQuery{})
+		for _, e := range entries {
+			if e.ID == propagated.ID() {
+				entry = e
+			}
+		}
+		return err == nil && entry.ID != ""
+	}, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, "req-42", entry.RequestID)
+}
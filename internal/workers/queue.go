@@ -1 +1,394 @@
 package worker
+
+import (
+	"container/heap"
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DefaultAgingInterval es el tiempo de espera que equivale a un punto de
+// prioridad. Es largo a propósito: solo debe adelantar a los jobs que llevan
+// minutos esperando, no reordenar una cola que se vacía normalmente.
+const DefaultAgingInterval = time.Minute
+
+// ErrQueueClosed se devuelve al operar sobre una cola cerrada
+var ErrQueueClosed = errors.New("queue is closed")
+
+// Queue define el contrato de una cola de jobs pendientes usada por el Pool
+type Queue interface {
+	// Push encola un item, bloqueando mientras la cola esté llena
+	Push(ctx context.Context, item *QueueItem) error
+	// Pop extrae el siguiente item, bloqueando mientras la cola esté vacía
+	Pop(ctx context.Context) (*QueueItem, error)
+	// PopFunc extrae el siguiente item que cumpla eligible, bloqueando mientras
+	// no haya ninguno. Los demás quedan en la cola sin perder su lugar.
+	PopFunc(ctx context.Context, eligible func(*QueueItem) bool) (*QueueItem, error)
+	// TakeFunc extrae sin bloquear todos los items que cumplan match. Como
+	// Pop, no los confirma: hay que llamar a Ack cuando terminen.
+	TakeFunc(match func(*QueueItem) bool) []*QueueItem
+	// Ack indica que el item terminó de procesarse (con éxito o no)
+	Ack(item *QueueItem) error
+	// Remove quita de la cola un item pendiente por su ID
+	Remove(id string) (*QueueItem, bool)
+	// RemoveOldest quita el item pendiente que lleva más tiempo encolado
+	RemoveOldest() (*QueueItem, bool)
+	// Len devuelve el número de items pendientes
+	Len() int
+	// Close libera los recursos de la cola y despierta a los que esperan
+	Close() error
+}
+
+// DurableQueue es una Queue que persiste su contenido y puede recuperarlo
+type DurableQueue interface {
+	Queue
+	// Recover recarga los items pendientes persistidos y los devuelve
+	Recover() ([]*QueueItem, error)
+}
+
+// QueueItem envuelve un Job con la metadata necesaria para ordenarlo en la cola
+type QueueItem struct {
+	ID         string
+	Job        Job
+	Priority   int
+	EnqueuedAt time.Time
+	// RequestID es el ID de la request que encoló el job (ver types.WithRequestID)
+	RequestID string
+	// captured son los valores de contexto de los ContextPropagator del pool
+	captured []any
+	seq      uint64
+	index    int
+}
+
+// newQueueItem crea un item con ID único para el job
+func newQueueItem(job Job) *QueueItem {
+	return &QueueItem{
+		ID:         uuid.NewString(),
+		Job:        job,
+		Priority:   job.Priority(),
+		EnqueuedAt: time.Now(),
+	}
+}
+
+// PriorityQueue es una cola acotada que entrega primero los jobs de mayor prioridad.
+//
+// Para evitar starvation, cada job gana un punto de prioridad por cada
+// agingInterval que pasa esperando. Como todos los jobs envejecen al mismo
+// ritmo, el orden relativo no cambia con el tiempo y se puede resolver con un
+// heap usando como clave EnqueuedAt - Priority*agingInterval.
+type PriorityQueue struct {
+	mu       sync.Mutex
+	items    priorityHeap
+	byID     map[string]*QueueItem
+	capacity int
+	// reserved son los lugares apartados con reserve que todavía no se ocuparon
+	reserved int
+	seq      uint64
+	changed  chan struct{}
+	closed   bool
+}
+
+// NewPriorityQueue crea una cola de prioridad con la capacidad indicada. Una
+// capacidad <= 0 crea una cola sin límite: Push nunca bloquea.
+// Un agingInterval de cero desactiva la protección contra starvation.
+func NewPriorityQueue(capacity int, agingInterval time.Duration) *PriorityQueue {
+	return &PriorityQueue{
+		items:    priorityHeap{aging: agingInterval},
+		byID:     make(map[string]*QueueItem),
+		capacity: capacity,
+		changed:  make(chan struct{}),
+	}
+}
+
+// Push encola un item, bloqueando mientras la cola esté llena
+func (q *PriorityQueue) Push(ctx context.Context, item *QueueItem) error {
+	if err := q.reserve(ctx); err != nil {
+		return err
+	}
+	q.pushReserved(item)
+	return nil
+}
+
+// reserve aparta un lugar en la cola, bloqueando mientras esté llena. El
+// lugar se ocupa con pushReserved o se libera con unreserve.
+func (q *PriorityQueue) reserve(ctx context.Context) error {
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return ErrQueueClosed
+		}
+
+		if q.capacity <= 0 || q.items.Len()+q.reserved < q.capacity {
+			q.reserved++
+			q.mu.Unlock()
+			return nil
+		}
+
+		wait := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Pop extrae el job más prioritario, bloqueando mientras la cola esté vacía
+func (q *PriorityQueue) Pop(ctx context.Context) (*QueueItem, error) {
+	return q.PopFunc(ctx, nil)
+}
+
+// PopFunc extrae el job más prioritario que cumpla eligible (cualquiera si es
+// nil), bloqueando mientras no haya ninguno
+func (q *PriorityQueue) PopFunc(ctx context.Context, eligible func(*QueueItem) bool) (*QueueItem, error) {
+	for {
+		q.mu.Lock()
+		if item := q.popEligible(eligible); item != nil {
+			delete(q.byID, item.ID)
+			q.broadcast()
+			q.mu.Unlock()
+			return item, nil
+		}
+
+		if q.closed {
+			q.mu.Unlock()
+			return nil, ErrQueueClosed
+		}
+
+		wait := q.changed
+		q.mu.Unlock()
+
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// TakeFunc extrae todos los items que cumplan match, en orden de prioridad
+func (q *PriorityQueue) TakeFunc(match func(*QueueItem) bool) []*QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var taken []*QueueItem
+	kept := q.items.items[:0]
+	for _, item := range q.items.items {
+		if match(item) {
+			taken = append(taken, item)
+			delete(q.byID, item.ID)
+		} else {
+			kept = append(kept, item)
+		}
+	}
+	if len(taken) == 0 {
+		return nil
+	}
+
+	clear(q.items.items[len(kept):])
+	q.items.items = kept
+	for i, item := range kept {
+		item.index = i
+	}
+	heap.Init(&q.items)
+	q.broadcast()
+
+	sort.Slice(taken, func(i, j int) bool {
+		return q.items.less(taken[i], taken[j])
+	})
+	return taken
+}
+
+// tryPop extrae el item más prioritario sin bloquear, o nil si la cola está vacía
+func (q *PriorityQueue) tryPop() *QueueItem {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.items.Len() == 0 {
+		return nil
+	}
+	item := heap.Pop(&q.items).(*QueueItem)
+	delete(q.byID, item.ID)
+	q.broadcast()
+	return item
+}
+
+// Ack no hace nada: la cola en memoria no necesita confirmación
+func (q *PriorityQueue) Ack(item *QueueItem) error {
+	return nil
+}
+
+// Remove quita de la cola un item pendiente por su ID
+func (q *PriorityQueue) Remove(id string) (*QueueItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	item, ok := q.byID[id]
+	if !ok {
+		return nil, false
+	}
+
+	heap.Remove(&q.items, item.index)
+	delete(q.byID, id)
+	q.broadcast()
+	return item, true
+}
+
+// RemoveOldest quita el item con el EnqueuedAt más antiguo, sin importar su prioridad
+func (q *PriorityQueue) RemoveOldest() (*QueueItem, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var oldest *QueueItem
+	for _, item := range q.items.items {
+		if oldest == nil || item.EnqueuedAt.Before(oldest.EnqueuedAt) ||
+			(item.EnqueuedAt.Equal(oldest.EnqueuedAt) && item.seq < oldest.seq) {
+			oldest = item
+		}
+	}
+	if oldest == nil {
+		return nil, false
+	}
+
+	heap.Remove(&q.items, oldest.index)
+	delete(q.byID, oldest.ID)
+	q.broadcast()
+	return oldest, true
+}
+
+// Len devuelve el número de jobs en la cola
+func (q *PriorityQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.items.Len()
+}
+
+// Close cierra la cola y despierta a todos los que estén esperando
+func (q *PriorityQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.closed {
+		q.closed = true
+		q.broadcast()
+	}
+	return nil
+}
+
+// popEligible extrae del heap el item más prioritario que cumpla eligible. Los
+// que se saltean vuelven al heap con su mismo seq, así conservan su orden
+// (requiere q.mu).
+func (q *PriorityQueue) popEligible(eligible func(*QueueItem) bool) *QueueItem {
+	var (
+		found   *QueueItem
+		skipped []*QueueItem
+	)
+	for q.items.Len() > 0 {
+		item := heap.Pop(&q.items).(*QueueItem)
+		if eligible == nil || eligible(item) {
+			found = item
+			break
+		}
+		skipped = append(skipped, item)
+	}
+
+	for _, item := range skipped {
+		heap.Push(&q.items, item)
+	}
+	return found
+}
+
+// pushReserved encola el item en el lugar apartado con reserve
+func (q *PriorityQueue) pushReserved(item *QueueItem) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reserved--
+	q.push(item)
+}
+
+// unreserve libera un lugar apartado con reserve que no se va a usar
+func (q *PriorityQueue) unreserve() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.reserved--
+	q.broadcast()
+}
+
+// forcePush encola ignorando la capacidad (usado al recuperar colas persistidas)
+func (q *PriorityQueue) forcePush(item *QueueItem) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.push(item)
+}
+
+// push inserta el item en el heap (requiere q.mu)
+func (q *PriorityQueue) push(item *QueueItem) {
+	q.seq++
+	item.seq = q.seq
+	heap.Push(&q.items, item)
+	q.byID[item.ID] = item
+	q.broadcast()
+}
+
+// broadcast despierta a todos los que esperan un cambio (requiere q.mu)
+func (q *PriorityQueue) broadcast() {
+	close(q.changed)
+	q.changed = make(chan struct{})
+}
+
+// priorityHeap implementa heap.Interface ordenando por prioridad efectiva
+type priorityHeap struct {
+	items []*QueueItem
+	aging time.Duration
+}
+
+func (h priorityHeap) Len() int { return len(h.items) }
+
+func (h priorityHeap) Less(i, j int) bool {
+	return h.less(h.items[i], h.items[j])
+}
+
+// less indica si a se entrega antes que b
+func (h priorityHeap) less(a, b *QueueItem) bool {
+	if h.aging > 0 {
+		da := a.EnqueuedAt.Add(-time.Duration(a.Priority) * h.aging)
+		db := b.EnqueuedAt.Add(-time.Duration(b.Priority) * h.aging)
+		if !da.Equal(db) {
+			return da.Before(db)
+		}
+	}
+
+	if a.Priority != b.Priority {
+		return a.Priority > b.Priority
+	}
+	return a.seq < b.seq
+}
+
+func (h priorityHeap) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+func (h *priorityHeap) Push(x interface{}) {
+	item := x.(*QueueItem)
+	item.index = len(h.items)
+	h.items = append(h.items, item)
+}
+
+func (h *priorityHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	item.index = -1
+	h.items = old[:n-1]
+	return item
+}
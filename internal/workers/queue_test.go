@@ -0,0 +1,153 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPriorityQueueOrder(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		aging time.Duration
+		items []*QueueItem
+		want  []string
+	}{
+		{
+			name:  "priority then arrival",
+			aging: 0,
+			items: []*QueueItem{
+				{ID: "low", Priority: 0, EnqueuedAt: now},
+				{ID: "high", Priority: 5, EnqueuedAt: now},
+				{ID: "low-2", Priority: 0, EnqueuedAt: now},
+				{ID: "high-2", Priority: 5, EnqueuedAt: now},
+			},
+			want: []string{"high", "high-2", "low", "low-2"},
+		},
+		{
+			name:  "aging lets an old job through",
+			aging: 5 * time.Second,
+			items: []*QueueItem{
+				{ID: "old", Priority: 0, EnqueuedAt: now.Add(-time.Minute)},
+				{ID: "new", Priority: 5, EnqueuedAt: now},
+			},
+			want: []string{"old", "new"},
+		},
+		{
+			name:  "aging keeps a much higher priority first",
+			aging: 5 * time.Second,
+			items: []*QueueItem{
+				{ID: "old", Priority: 0, EnqueuedAt: now.Add(-time.Minute)},
+				{ID: "urgent", Priority: 20, EnqueuedAt: now},
+			},
+			want: []string{"urgent", "old"},
+		},
+		{
+			name:  "default aging does not reorder a short wait",
+			aging: DefaultAgingInterval,
+			items: []*QueueItem{
+				{ID: "waiting", Priority: 0, EnqueuedAt: now.Add(-30 * time.Second)},
+				{ID: "urgent", Priority: 1, EnqueuedAt: now},
+			},
+			want: []string{"urgent", "waiting"},
+		},
+		{
+			name:  "without aging the old job starves",
+			aging: 0,
+			items: []*QueueItem{
+				{ID: "old", Priority: 0, EnqueuedAt: now.Add(-time.Hour)},
+				{ID: "new", Priority: 1, EnqueuedAt: now},
+			},
+			want: []string{"new", "old"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewPriorityQueue(0, tt.aging)
+			for _, item := range tt.items {
+				item.Job = newTestJob(item.ID, nil)
+				assert.NoError(t, q.Push(context.Background(), item))
+			}
+
+			got := make([]string, 0, len(tt.items))
+			for q.Len() > 0 {
+				item, err := q.Pop(context.Background())
+				assert.NoError(t, err)
+				got = append(got, item.ID)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPriorityQueueCapacity(t *testing.T) {
+	q := NewPriorityQueue(1, 0)
+	assert.NoError(t, q.Push(context.Background(), newTestItem("first", 0)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, q.Push(ctx, newTestItem("second", 0)), context.DeadlineExceeded)
+
+	// Un Pop libera lugar para el Push bloqueado
+	pushed := make(chan error, 1)
+	go func() {
+		pushed <- q.Push(context.Background(), newTestItem("third", 0))
+	}()
+	item, err := q.Pop(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "first", item.Job.Name())
+	assert.NoError(t, <-pushed)
+	assert.Equal(t, 1, q.Len())
+}
+
+func TestPriorityQueueRemove(t *testing.T) {
+	q := NewPriorityQueue(0, 0)
+	first := newTestItem("first", 0)
+	second := newTestItem("second", 5)
+	third := newTestItem("third", 0)
+	for _, item := range []*QueueItem{first, second, third} {
+		assert.NoError(t, q.Push(context.Background(), item))
+	}
+
+	removed, ok := q.Remove(second.ID)
+	assert.True(t, ok)
+	assert.Same(t, second, removed)
+	_, ok = q.Remove(second.ID)
+	assert.False(t, ok)
+
+	oldest, ok := q.RemoveOldest()
+	assert.True(t, ok)
+	assert.Same(t, first, oldest)
+
+	item, err := q.Pop(context.Background())
+	assert.NoError(t, err)
+	assert.Same(t, third, item)
+}
+
+func TestPriorityQueueClose(t *testing.T) {
+	q := NewPriorityQueue(0, 0)
+	assert.NoError(t, q.Push(context.Background(), newTestItem("queued", 0)))
+
+	popped := make(chan error, 1)
+	empty := NewPriorityQueue(0, 0)
+	go func() {
+		_, err := empty.Pop(context.Background())
+		popped <- err
+	}()
+	assert.NoError(t, empty.Close())
+	assert.ErrorIs(t, <-popped, ErrQueueClosed)
+
+	// Lo encolado se sigue entregando después de cerrar, pero no entra nada nuevo
+	assert.NoError(t, q.Close())
+	assert.ErrorIs(t, q.Push(context.Background(), newTestItem("late", 0)), ErrQueueClosed)
+	item, err := q.Pop(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "queued", item.Job.Name())
+	_, err = q.Pop(context.Background())
+	assert.ErrorIs(t, err, ErrQueueClosed)
+}
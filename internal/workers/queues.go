@@ -0,0 +1,284 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// DefaultQueueName es el nombre de la cola que recibe los jobs sin una cola asignada
+const DefaultQueueName = "default"
+
+// ErrQueueFull es el error de envío cuando la cola está llena y no se puede
+// esperar lugar (OverflowReject, TrySubmit o el ctx de SubmitWithContext vencido)
+var ErrQueueFull = errors.New("queue is full")
+
+// OverflowPolicy define qué hacer cuando se envía un job con la cola llena
+type OverflowPolicy int
+
+const (
+	// OverflowBlock bloquea el envío hasta que haya lugar en la cola
+	OverflowBlock OverflowPolicy = iota
+	// OverflowReject rechaza el envío con ErrQueueFull
+	OverflowReject
+	// OverflowDropOldest descarta el job que lleva más tiempo encolado para
+	// hacerle lugar al nuevo. El descartado termina con ErrJobDropped y va al
+	// dead-letter store.
+	OverflowDropOldest
+	// OverflowSpillToDisk desvía los jobs que no entran a la cola de WithSpillQueue,
+	// de donde vuelven a la cola a medida que se libera lugar
+	OverflowSpillToDisk
+)
+
+// String devuelve el nombre de la política
+func (p OverflowPolicy) String() string {
+	switch p {
+	case OverflowReject:
+		return "reject"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowSpillToDisk:
+		return "spill"
+	default:
+		return "block"
+	}
+}
+
+// MarshalText serializa la política por nombre
+func (p OverflowPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+// WithOverflowPolicy define qué hace el pool cuando se envía un job con la
+// cola llena (por defecto OverflowBlock)
+func WithOverflowPolicy(policy OverflowPolicy) PoolOption {
+	return func(c *poolConfig) {
+		c.overflow = policy
+	}
+}
+
+// withPoolName identifica al pool en los logs
+func withPoolName(name string) PoolOption {
+	return func(c *poolConfig) {
+		c.name = name
+	}
+}
+
+// QueueConfig describe una cola con nombre y sus workers dedicados
+type QueueConfig struct {
+	// Workers es la cantidad de workers dedicados a la cola
+	Workers int
+	// Size es la capacidad de la cola (<= 0 = sin límite, sin overflow)
+	Size int
+	// Overflow es la política cuando la cola está llena. OverflowSpillToDisk
+	// requiere WithSpillQueue en PoolOptions.
+	Overflow OverflowPolicy
+	// JobPrefixes enruta a esta cola los jobs cuyo nombre empieza con alguno de
+	// los prefijos (gana el prefijo más largo entre todas las colas)
+	JobPrefixes []string
+	// Middleware envuelve la ejecución de los jobs de la cola, dentro de los
+	// middlewares de WithMiddleware
+	Middleware []Middleware
+	// PoolOptions configura el pool de la cola. Las opciones de WithPoolOptions
+	// solo aplican a la cola por defecto.
+	PoolOptions []PoolOption
+}
+
+// WithNamedQueue agrega una cola con nombre y workers propios, para que un
+// tipo de job lento no acapare los workers de los demás. Los jobs llegan a la
+// cola si implementan types.QueuedJob con este nombre o si su nombre coincide
+// con alguno de los JobPrefixes.
+func WithNamedQueue(name string, cfg QueueConfig) DispatcherOption {
+	return func(c *dispatcherConfig) {
+		if c.queues == nil {
+			c.queues = make(map[string]QueueConfig)
+		}
+		c.queues[name] = cfg
+	}
+}
+
+// QueueStats es el estado de una cola y de sus workers
+type QueueStats struct {
+	Workers     int `json:"workers"`
+	IdleWorkers int `json:"idle_workers"`
+	PendingJobs int `json:"pending_jobs"`
+	// PausedJobs son los jobs retenidos por un prefijo pausado con PauseJobs
+	PausedJobs int `json:"paused_jobs"`
+	// ThrottledJobs son los jobs que esperan un token de su límite de tasa
+	ThrottledJobs int            `json:"throttled_jobs"`
+	Overflow      OverflowPolicy `json:"overflow"`
+	Paused        bool           `json:"paused"`
+	Metrics       PoolMetrics    `json:"metrics"`
+}
+
+// queueRoute asocia un prefijo de nombre de job con una cola
+type queueRoute struct {
+	prefix string
+	pool   *Pool
+}
+
+// queueSet son los pools de las colas del dispatcher, incluida la por defecto
+type queueSet struct {
+	pools  map[string]*Pool
+	routes []queueRoute
+}
+
+// newQueueSet crea un pool por cada cola con nombre
+func newQueueSet(defaultPool *Pool, queues map[string]QueueConfig, shared []PoolOption) *queueSet {
+	set := &queueSet{pools: map[string]*Pool{DefaultQueueName: defaultPool}}
+
+	for name, cfg := range queues {
+		if name == DefaultQueueName {
+			continue
+		}
+
+		workers := max(cfg.Workers, 1)
+		options := append([]PoolOption{WithOverflowPolicy(cfg.Overflow), WithPoolMiddleware(cfg.Middleware...)}, cfg.PoolOptions...)
+		options = append(options, shared...)
+		options = append(options, withPoolName(name))
+		pool := NewWorkerPool(workers, cfg.Size, options...)
+
+		set.pools[name] = pool
+		for _, prefix := range cfg.JobPrefixes {
+			set.routes = append(set.routes, queueRoute{prefix: prefix, pool: pool})
+		}
+	}
+
+	// Más largo primero para que gane el prefijo más específico
+	slices.SortFunc(set.routes, func(a, b queueRoute) int {
+		return len(b.prefix) - len(a.prefix)
+	})
+	return set
+}
+
+// route elige la cola del job: la que declara, la del prefijo más largo que
+// coincide con su nombre o la cola por defecto
+func (s *queueSet) route(job Job) (*Pool, error) {
+	if queued, ok := job.(types.QueuedJob); ok && queued.QueueName() != "" {
+		pool, exists := s.pools[queued.QueueName()]
+		if !exists {
+			return nil, fmt.Errorf("unknown queue %q for job %s", queued.QueueName(), job.Name())
+		}
+		return pool, nil
+	}
+
+	name := job.Name()
+	for _, route := range s.routes {
+		if strings.HasPrefix(name, route.prefix) {
+			return route.pool, nil
+		}
+	}
+	return s.pools[DefaultQueueName], nil
+}
+
+// names devuelve los nombres de las colas ordenados
+func (s *queueSet) names() []string {
+	return slices.Sorted(maps.Keys(s.pools))
+}
+
+// all devuelve los pools de todas las colas
+func (s *queueSet) all() []*Pool {
+	pools := make([]*Pool, 0, len(s.pools))
+	for _, name := range s.names() {
+		pools = append(pools, s.pools[name])
+	}
+	return pools
+}
+
+// find devuelve el pool que rastrea el job con el ID indicado
+func (s *queueSet) find(id string) (*Pool, bool) {
+	for _, pool := range s.pools {
+		if _, ok := pool.jobs.get(id); ok {
+			return pool, true
+		}
+	}
+	return nil, false
+}
+
+// drain drena todas las colas en paralelo y junta sus reportes
+func (s *queueSet) drain(ctx context.Context) (DrainReport, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report DrainReport
+		errs   []error
+	)
+
+	for _, name := range s.names() {
+		pool := s.pools[name]
+		wg.Go(func() {
+			poolReport, err := pool.Drain(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Abandoned = append(report.Abandoned, poolReport.Abandoned...)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("queue %s: %w", name, err))
+			}
+		})
+	}
+	wg.Wait()
+
+	return report, errors.Join(errs...)
+}
+
+// stats devuelve el estado de cada cola
+func (s *queueSet) stats() map[string]QueueStats {
+	stats := make(map[string]QueueStats, len(s.pools))
+	for name, pool := range s.pools {
+		stats[name] = QueueStats{
+			Workers:       pool.Size(),
+			IdleWorkers:   pool.Idle(),
+			PendingJobs:   pool.Pending(),
+			PausedJobs:    pool.held.len(),
+			ThrottledJobs: pool.throttled.len(),
+			Overflow:      pool.overflow,
+			Paused:        pool.Paused(),
+			Metrics:       pool.Metrics(),
+		}
+	}
+	return stats
+}
+
+// mergeMetrics suma las métricas de varias colas
+func mergeMetrics(all []PoolMetrics) PoolMetrics {
+	merged := PoolMetrics{Jobs: make(map[string]JobCounts)}
+	waitTime := make([]HistogramSnapshot, 0, len(all))
+	execTime := make([]HistogramSnapshot, 0, len(all))
+
+	for _, metrics := range all {
+		merged.Submitted += metrics.Submitted
+		merged.Deduplicated += metrics.Deduplicated
+		merged.Rejected += metrics.Rejected
+		merged.Dropped += metrics.Dropped
+		merged.Spilled += metrics.Spilled
+		merged.Succeeded += metrics.Succeeded
+		merged.Failed += metrics.Failed
+		merged.Cancelled += metrics.Cancelled
+		merged.Panicked += metrics.Panicked
+		merged.InFlight += metrics.InFlight
+		merged.Throughput += metrics.Throughput
+		merged.Workers = append(merged.Workers, metrics.Workers...)
+		waitTime = append(waitTime, metrics.WaitTime)
+		execTime = append(execTime, metrics.ExecTime)
+
+		for name, counts := range metrics.Jobs {
+			total := merged.Jobs[name]
+			total.Succeeded += counts.Succeeded
+			total.Failed += counts.Failed
+			total.Cancelled += counts.Cancelled
+			total.Panicked += counts.Panicked
+			merged.Jobs[name] = total
+		}
+	}
+
+	merged.WaitTime = mergeHistograms(waitTime)
+	merged.ExecTime = mergeHistograms(execTime)
+	return merged
+}
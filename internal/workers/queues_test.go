@@ -0,0 +1,127 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// namedQueueJob es un testJob que declara su cola
+type namedQueueJob struct {
+	*testJob
+	queue string
+}
+
+func (j *namedQueueJob) QueueName() string { return j.queue }
+
+// newRoutingDispatcher crea un dispatcher con colas que comparten prefijo
+func newRoutingDispatcher(t *testing.T) *Dispatcher {
+	d := NewDispatcher(context.Background(), 1, 10,
+		WithNamedQueue("reports", QueueConfig{Workers: 1, Size: 10, JobPrefixes: []string{"report-"}}),
+		WithNamedQueue("urgent", QueueConfig{Workers: 1, Size: 10, JobPrefixes: []string{"report-urgent-"}}),
+		WithNamedQueue("batch", QueueConfig{Workers: 1, Size: 10}),
+	)
+	assert.NoError(t, d.Start())
+	t.Cleanup(d.Stop)
+	return d
+}
+
+func TestQueueRouting(t *testing.T) {
+	d := newRoutingDispatcher(t)
+
+	tests := []struct {
+		name string
+		job  Job
+		want string
+	}{
+		{"no prefix goes to default", newTestJob("price-1", nil), DefaultQueueName},
+		{"matching prefix", newTestJob("report-daily", nil), "reports"},
+		{"longest prefix wins", newTestJob("report-urgent-1", nil), "urgent"},
+		{"declared queue", &namedQueueJob{testJob: newTestJob("nightly", nil), queue: "batch"}, "batch"},
+		{"declared queue beats prefix", &namedQueueJob{testJob: newTestJob("report-weekly", nil), queue: "batch"}, "batch"},
+		{"empty declared queue falls back to prefix", &namedQueueJob{testJob: newTestJob("report-monthly", nil)}, "reports"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handle, err := d.Submit(tt.job)
+			assert.NoError(t, err)
+
+			pool, ok := d.queues.find(handle.ID())
+			if assert.True(t, ok) {
+				assert.Same(t, d.queues.pools[tt.want], pool)
+			}
+		})
+	}
+}
+
+func TestUnknownQueueIsRejected(t *testing.T) {
+	d := newRoutingDispatcher(t)
+
+	handle, err := d.Submit(&namedQueueJob{testJob: newTestJob("lost", nil), queue: "missing"})
+	assert.Nil(t, handle)
+	assert.ErrorContains(t, err, `unknown queue "missing"`)
+
+	for name, queue := range d.Stats().Queues {
+		assert.Zero(t, queue.Metrics.Submitted, name)
+	}
+}
+
+func TestQueueStatsAreIsolated(t *testing.T) {
+	d := newRoutingDispatcher(t)
+
+	release := make(chan struct{})
+	defer close(release)
+	running := make(chan struct{})
+	_, err := d.Submit(newTestJob("report-urgent-1", func(context.Context) error {
+		close(running)
+		<-release
+		return nil
+	}))
+	assert.NoError(t, err)
+	<-running
+	_, err = d.Submit(newTestJob("report-urgent-2", nil))
+	assert.NoError(t, err)
+
+	for i := range 3 {
+		_, err := d.Submit(newTestJob(fmt.Sprintf("price-%d", i), nil))
+		assert.NoError(t, err)
+	}
+	for i := range 2 {
+		_, err := d.Submit(newTestJob(fmt.Sprintf("report-%d", i), func(context.Context) error {
+			return errors.New("boom")
+		}))
+		assert.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		queues := d.Stats().Queues
+		return queues[DefaultQueueName].Metrics.Succeeded == 3 && queues["reports"].Metrics.Failed == 2
+	}, 2*time.Second, 5*time.Millisecond)
+
+	queues := d.Stats().Queues
+	assert.Equal(t, PoolMetrics{Submitted: 3, Succeeded: 3}, countsOf(queues[DefaultQueueName].Metrics))
+	assert.Equal(t, PoolMetrics{Submitted: 2, Failed: 2}, countsOf(queues["reports"].Metrics))
+	assert.Equal(t, PoolMetrics{Submitted: 2, InFlight: 1}, countsOf(queues["urgent"].Metrics))
+	assert.Equal(t, PoolMetrics{}, countsOf(queues["batch"].Metrics))
+
+	assert.Equal(t, 1, queues["urgent"].PendingJobs)
+	for _, name := range []string{DefaultQueueName, "reports", "batch"} {
+		assert.Zero(t, queues[name].PendingJobs, name)
+	}
+}
+
+// countsOf deja solo los contadores de las métricas para compararlas
+func countsOf(metrics PoolMetrics) PoolMetrics {
+	return PoolMetrics{
+		Submitted: metrics.Submitted,
+		Succeeded: metrics.Succeeded,
+		Failed:    metrics.Failed,
+		Cancelled: metrics.Cancelled,
+		InFlight:  metrics.InFlight,
+	}
+}
@@ -0,0 +1,372 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// TokenBucket limita la tasa de ejecuciones: acumula hasta burst tokens y
+// los repone a rate tokens por segundo
+type TokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  int
+	tokens float64
+	last   time.Time
+
+	throttled atomic.Int64
+}
+
+// NewTokenBucket crea un bucket lleno con la tasa y ráfaga indicadas
+func NewTokenBucket(rate float64, burst int) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+	return &TokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// SetLimit cambia la tasa y la ráfaga en caliente
+func (b *TokenBucket) SetLimit(rate float64, burst int) {
+	if burst < 1 {
+		burst = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	b.rate = rate
+	b.burst = burst
+	b.tokens = math.Min(b.tokens, float64(burst))
+}
+
+// Limit devuelve la tasa y la ráfaga actuales
+func (b *TokenBucket) Limit() (float64, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate, b.burst
+}
+
+// Wait bloquea hasta obtener un token o hasta que se cancele el contexto
+func (b *TokenBucket) Wait(ctx context.Context) error {
+	throttled := false
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.refill(now)
+
+		if b.tokens >= 1 {
+			b.tokens--
+			b.mu.Unlock()
+			return nil
+		}
+
+		wait := b.wait()
+		b.mu.Unlock()
+
+		if !throttled {
+			throttled = true
+			b.throttled.Add(1)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+// take toma un token sin bloquear. Si no hay, devuelve false y cuánto falta
+// aproximadamente para que haya uno.
+func (b *TokenBucket) take() (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0, true
+	}
+	return b.wait(), false
+}
+
+// delay devuelve cuánto falta aproximadamente para que haya un token, sin tomarlo
+func (b *TokenBucket) delay() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refill(time.Now())
+	if b.tokens >= 1 {
+		return 0
+	}
+	return b.wait()
+}
+
+// wait estima cuánto falta para el próximo token (requiere b.mu)
+func (b *TokenBucket) wait() time.Duration {
+	// Con tasa cero el recurso está bloqueado: se reintenta periódicamente
+	// por si la tasa cambia en caliente
+	if b.rate <= 0 {
+		return time.Second
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+// refill repone los tokens acumulados desde la última vez (requiere b.mu)
+func (b *TokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	b.last = now
+	b.tokens = math.Min(float64(b.burst), b.tokens+elapsed*b.rate)
+}
+
+// RateLimitStatus describe un límite configurado
+type RateLimitStatus struct {
+	Key       string  `json:"key"`
+	Rate      float64 `json:"rate"`
+	Burst     int     `json:"burst"`
+	Throttled int64   `json:"throttled"`
+}
+
+// RateLimiter asocia límites de tasa a recursos o prefijos de nombre de job.
+// Un job que declara types.ResourceJob usa el límite de su recurso; si no hay
+// uno se usa el del prefijo más largo que coincida con su nombre.
+type RateLimiter struct {
+	mu      sync.RWMutex
+	buckets map[string]*TokenBucket
+}
+
+// NewRateLimiter crea un limitador sin límites configurados
+func NewRateLimiter() *RateLimiter {
+	return &RateLimiter{buckets: make(map[string]*TokenBucket)}
+}
+
+// Set crea o actualiza el límite de una clave
+func (r *RateLimiter) Set(key string, rate float64, burst int) error {
+	if key == "" {
+		return fmt.Errorf("rate limit key cannot be empty")
+	}
+	if rate < 0 {
+		return fmt.Errorf("rate limit for %s must not be negative", key)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if bucket, exists := r.buckets[key]; exists {
+		bucket.SetLimit(rate, burst)
+		return nil
+	}
+	r.buckets[key] = NewTokenBucket(rate, burst)
+	return nil
+}
+
+// Remove elimina el límite de una clave y devuelve si existía
+func (r *RateLimiter) Remove(key string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.buckets[key]; !exists {
+		return false
+	}
+	delete(r.buckets, key)
+	return true
+}
+
+// For devuelve el bucket aplicable al job, o nil si no tiene límite
+func (r *RateLimiter) For(job Job) *TokenBucket {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if len(r.buckets) == 0 {
+		return nil
+	}
+
+	if resourceJob, ok := job.(types.ResourceJob); ok {
+		if bucket, exists := r.buckets[resourceJob.ResourceKey()]; exists {
+			return bucket
+		}
+	}
+
+	var match *TokenBucket
+	longest := -1
+	name := job.Name()
+	for key, bucket := range r.buckets {
+		if strings.HasPrefix(name, key) && len(key) > longest {
+			match = bucket
+			longest = len(key)
+		}
+	}
+	return match
+}
+
+// Wait bloquea hasta que el job pueda ejecutarse según su límite
+func (r *RateLimiter) Wait(ctx context.Context, job Job) error {
+	if bucket := r.For(job); bucket != nil {
+		return bucket.Wait(ctx)
+	}
+	return nil
+}
+
+// Status devuelve el estado de todos los límites ordenados por clave
+func (r *RateLimiter) Status() []RateLimitStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	status := make([]RateLimitStatus, 0, len(r.buckets))
+	for key, bucket := range r.buckets {
+		rate, burst := bucket.Limit()
+		status = append(status, RateLimitStatus{
+			Key:       key,
+			Rate:      rate,
+			Burst:     burst,
+			Throttled: bucket.throttled.Load(),
+		})
+	}
+
+	sort.Slice(status, func(i, j int) bool {
+		return status[i].Key < status[j].Key
+	})
+	return status
+}
+
+// throttledJobs guarda los jobs extraídos de la cola que no obtuvieron token
+// de su límite de tasa, en orden de llegada por bucket, hasta que haya uno.
+// Así esperan sin ocupar un worker ni consumir su timeout ni sus intentos, y
+// los jobs de otros límites siguen entregándose. Solo se revisa el primero de
+// cada bucket, cuando vence su espera. Como los pausados, no se confirman.
+type throttledJobs struct {
+	mu       sync.Mutex
+	byBucket map[*TokenBucket]*throttledLine
+}
+
+// throttledLine son los jobs que esperan un token del mismo bucket
+type throttledLine struct {
+	items   []*QueueItem
+	retryAt time.Time
+}
+
+func newThrottledJobs() *throttledJobs {
+	return &throttledJobs{byBucket: make(map[*TokenBucket]*throttledLine)}
+}
+
+// waiting indica si hay jobs esperando un token del bucket: los nuevos van detrás
+func (t *throttledJobs) waiting(bucket *TokenBucket) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.byBucket[bucket]
+	return ok
+}
+
+// add pone el item al final de la fila del bucket
+func (t *throttledJobs) add(bucket *TokenBucket, item *QueueItem) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	bucket.throttled.Add(1)
+	line, ok := t.byBucket[bucket]
+	if !ok {
+		line = &throttledLine{retryAt: time.Now().Add(bucket.delay())}
+		t.byBucket[bucket] = line
+	}
+	line.items = append(line.items, item)
+}
+
+// next devuelve el primer job de una fila cuya espera venció y que obtuvo un
+// token, o nil y el momento de la próxima revisión (cero si no hay filas)
+func (t *throttledJobs) next(limits *RateLimiter) (*QueueItem, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	var wake time.Time
+	for bucket, line := range t.byBucket {
+		if now.Before(line.retryAt) {
+			if wake.IsZero() || line.retryAt.Before(wake) {
+				wake = line.retryAt
+			}
+			continue
+		}
+
+		// El límite pudo cambiar o eliminarse mientras esperaba
+		head := line.items[0]
+		current := limits.For(head.Job)
+		wait, ok := time.Duration(0), current == nil
+		if current != nil {
+			wait, ok = current.take()
+		}
+		if !ok {
+			line.retryAt = now.Add(wait)
+			if wake.IsZero() || line.retryAt.Before(wake) {
+				wake = line.retryAt
+			}
+			continue
+		}
+
+		line.items = line.items[1:]
+		if len(line.items) == 0 {
+			delete(t.byBucket, bucket)
+		}
+		return head, time.Time{}
+	}
+	return nil, wake
+}
+
+// remove quita un job que espera un token por su ID
+func (t *throttledJobs) remove(id string) (*QueueItem, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for bucket, line := range t.byBucket {
+		for i, item := range line.items {
+			if item.ID == id {
+				line.items = slices.Delete(line.items, i, i+1)
+				if len(line.items) == 0 {
+					delete(t.byBucket, bucket)
+				}
+				return item, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// len devuelve cuántos jobs esperan un token
+func (t *throttledJobs) len() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n := 0
+	for _, line := range t.byBucket {
+		n += len(line.items)
+	}
+	return n
+}
+
+// takeAll extrae todos los jobs que esperan un token
+func (t *throttledJobs) takeAll() []*QueueItem {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var items []*QueueItem
+	for _, line := range t.byBucket {
+		items = append(items, line.items...)
+	}
+	clear(t.byBucket)
+	return items
+}
@@ -0,0 +1,48 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// timeoutJob es un testJob con su propio timeout
+type timeoutJob struct {
+	*testJob
+	timeout time.Duration
+}
+
+func (j *timeoutJob) Timeout() time.Duration { return j.timeout }
+
+func TestThrottledJobsDoNotHoldWorkers(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10, WithRateLimit("limited-", 0, 1))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	handles := make([]*JobHandle, 0, 2)
+	for range 2 {
+		handle, err := d.Submit(&timeoutJob{testJob: newTestJob("limited-job", nil), timeout: 50 * time.Millisecond})
+		assert.NoError(t, err)
+		handles = append(handles, handle)
+	}
+	other, err := d.Submit(newTestJob("other-job", nil))
+	assert.NoError(t, err)
+
+	// Con tasa cero el segundo espera un token sin ocupar al único worker ni
+	// consumir su timeout
+	waitStatus(t, func() JobStatus { return other.Status().Status }, JobSucceeded)
+	waitStatus(t, func() JobStatus { return handles[0].Status().Status }, JobSucceeded)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, JobQueued, handles[1].Status().Status)
+	assert.Equal(t, 1, d.Stats().Queues[DefaultQueueName].ThrottledJobs)
+
+	assert.NoError(t, d.SetRateLimit("limited-", 100, 1))
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	result, err := handles[1].Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+	assert.Equal(t, 1, result.Attempts)
+}
@@ -0,0 +1,80 @@
+package worker
+
+import (
+	"fmt"
+	"sync"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// JobDecoder reconstruye un job a partir de su payload serializado
+type JobDecoder func(payload []byte) (types.Job, error)
+
+// JobRegistry asocia tipos de job con sus decoders para poder rehidratarlos
+type JobRegistry struct {
+	mu       sync.RWMutex
+	decoders map[string]JobDecoder
+}
+
+// NewJobRegistry crea un registro de tipos de job vacío
+func NewJobRegistry() *JobRegistry {
+	return &JobRegistry{
+		decoders: make(map[string]JobDecoder),
+	}
+}
+
+// Register registra el decoder para un tipo de job
+func (r *JobRegistry) Register(jobType string, decoder JobDecoder) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.decoders[jobType]; exists {
+		return fmt.Errorf("job type %q already registered", jobType)
+	}
+
+	r.decoders[jobType] = decoder
+	return nil
+}
+
+// Encode serializa un job si implementa SerializableJob y su tipo está registrado
+func (r *JobRegistry) Encode(job Job) (string, []byte, error) {
+	serializable, ok := job.(types.SerializableJob)
+	if !ok {
+		return "", nil, fmt.Errorf("job %s is not serializable", job.Name())
+	}
+
+	jobType := serializable.JobType()
+
+	r.mu.RLock()
+	_, registered := r.decoders[jobType]
+	r.mu.RUnlock()
+
+	if !registered {
+		return "", nil, fmt.Errorf("job type %q is not registered", jobType)
+	}
+
+	payload, err := serializable.Marshal()
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to marshal job %s: %w", job.Name(), err)
+	}
+
+	return jobType, payload, nil
+}
+
+// Decode reconstruye un job usando el decoder registrado para su tipo
+func (r *JobRegistry) Decode(jobType string, payload []byte) (Job, error) {
+	r.mu.RLock()
+	decoder, ok := r.decoders[jobType]
+	r.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("job type %q is not registered", jobType)
+	}
+
+	job, err := decoder(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode job of type %q: %w", jobType, err)
+	}
+
+	return job, nil
+}
@@ -0,0 +1,147 @@
+package worker
+
+import (
+	"sync"
+	"time"
+)
+
+const maxScalingEvents = 50
+
+// ScalingPolicy define cómo el dispatcher ajusta el número de workers según la carga
+type ScalingPolicy struct {
+	// MinWorkers y MaxWorkers acotan el tamaño del pool
+	MinWorkers int
+	MaxWorkers int
+	// CheckInterval es cada cuánto se evalúa la carga
+	CheckInterval time.Duration
+	// ScaleUpThreshold es la cantidad de jobs pendientes por worker que se considera carga alta
+	ScaleUpThreshold int
+	// ScaleUpPeriods es cuántas evaluaciones seguidas con carga alta hacen falta para crecer
+	ScaleUpPeriods int
+	// ScaleDownPeriods es cuántas evaluaciones seguidas sin pendientes y con workers ociosos hacen falta para achicar
+	ScaleDownPeriods int
+	// Step es cuántos workers se agregan o retiran por evento
+	Step int
+}
+
+// DefaultScalingPolicy devuelve una política razonable para los límites indicados
+func DefaultScalingPolicy(minWorkers, maxWorkers int) ScalingPolicy {
+	return ScalingPolicy{
+		MinWorkers:       minWorkers,
+		MaxWorkers:       maxWorkers,
+		CheckInterval:    5 * time.Second,
+		ScaleUpThreshold: 2,
+		ScaleUpPeriods:   2,
+		ScaleDownPeriods: 6,
+		Step:             1,
+	}
+}
+
+// ScalingEvent registra un cambio en el tamaño del pool
+type ScalingEvent struct {
+	Time   time.Time `json:"time"`
+	From   int       `json:"from"`
+	To     int       `json:"to"`
+	Reason string    `json:"reason"`
+}
+
+// autoscaler evalúa la carga del pool y aplica la política de escalado
+type autoscaler struct {
+	policy   ScalingPolicy
+	pool     *Pool
+	highLoad int
+	idle     int
+
+	mu     sync.Mutex
+	events []ScalingEvent
+}
+
+func newAutoscaler(policy ScalingPolicy, pool *Pool) *autoscaler {
+	if policy.Step < 1 {
+		policy.Step = 1
+	}
+	if policy.ScaleUpThreshold < 1 {
+		policy.ScaleUpThreshold = 1
+	}
+	if policy.CheckInterval <= 0 {
+		policy.CheckInterval = 5 * time.Second
+	}
+
+	return &autoscaler{
+		policy: policy,
+		pool:   pool,
+	}
+}
+
+// evaluate revisa la carga actual y agrega o retira workers si corresponde
+func (a *autoscaler) evaluate() *ScalingEvent {
+	// Un pool pausado acumula jobs a propósito: no es carga para escalar. Los
+	// retenidos por un prefijo pausado ya no cuentan en Pending, y los que
+	// esperan su clave de orden no cuentan en runnable.
+	if a.pool.Paused() {
+		a.highLoad, a.idle = 0, 0
+		return nil
+	}
+
+	size := a.pool.Size()
+	pending := a.pool.runnable()
+	idle := a.pool.Idle()
+
+	if pending >= size*a.policy.ScaleUpThreshold {
+		a.highLoad++
+	} else {
+		a.highLoad = 0
+	}
+
+	if pending == 0 && idle > 0 {
+		a.idle++
+	} else {
+		a.idle = 0
+	}
+
+	if a.highLoad >= a.policy.ScaleUpPeriods {
+		a.highLoad = 0
+		if added := a.pool.AddWorkers(a.policy.Step); added > 0 {
+			return a.record(size, size+added, "sustained pending jobs")
+		}
+	}
+
+	if a.idle >= a.policy.ScaleDownPeriods {
+		a.idle = 0
+		step := min(a.policy.Step, idle)
+		if removed := a.pool.RemoveWorkers(step); removed > 0 {
+			return a.record(size, size-removed, "idle workers")
+		}
+	}
+
+	return nil
+}
+
+// record guarda el evento en el historial acotado
+func (a *autoscaler) record(from, to int, reason string) *ScalingEvent {
+	event := ScalingEvent{
+		Time:   time.Now(),
+		From:   from,
+		To:     to,
+		Reason: reason,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.events = append(a.events, event)
+	if len(a.events) > maxScalingEvents {
+		a.events = a.events[len(a.events)-maxScalingEvents:]
+	}
+	return &event
+}
+
+// Events devuelve una copia de los últimos eventos de escalado
+func (a *autoscaler) Events() []ScalingEvent {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	events := make([]ScalingEvent, len(a.events))
+	copy(events, a.events)
+	return events
+}
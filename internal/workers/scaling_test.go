@@ -0,0 +1,115 @@
+package worker
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testScalingPolicy reacciona en dos evaluaciones para que los tests llamen a
+// evaluate directamente en lugar de esperar al monitor
+func testScalingPolicy(minWorkers, maxWorkers int) ScalingPolicy {
+	return ScalingPolicy{
+		MinWorkers:       minWorkers,
+		MaxWorkers:       maxWorkers,
+		ScaleUpThreshold: 2,
+		ScaleUpPeriods:   2,
+		ScaleDownPeriods: 2,
+		Step:             1,
+	}
+}
+
+func TestAutoscalerScalesUpToMax(t *testing.T) {
+	pool := NewWorkerPool(2, 20, WithMinWorkers(1))
+	assert.NoError(t, pool.Start())
+	defer pool.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+	blocking := func(ctx context.Context) error {
+		select {
+		case <-release:
+		case <-ctx.Done():
+		}
+		return nil
+	}
+	for i := range 8 {
+		assert.NoError(t, pool.Submit(newTestJob(fmt.Sprintf("job-%d", i), blocking)))
+	}
+	assert.Eventually(t, func() bool { return pool.Pending() == 7 }, time.Second, 5*time.Millisecond)
+
+	scaler := newAutoscaler(testScalingPolicy(1, 2), pool)
+	assert.Nil(t, scaler.evaluate(), "a single period of load is not enough")
+
+	event := scaler.evaluate()
+	if assert.NotNil(t, event) {
+		assert.Equal(t, 1, event.From)
+		assert.Equal(t, 2, event.To)
+	}
+	assert.Equal(t, 2, pool.Size())
+
+	// Sigue habiendo carga, pero el pool ya está en su máximo
+	for range 4 {
+		assert.Nil(t, scaler.evaluate())
+	}
+	assert.Equal(t, 2, pool.Size())
+	assert.Len(t, scaler.Events(), 1)
+}
+
+func TestAutoscalerScalesDownToMin(t *testing.T) {
+	pool := NewWorkerPool(4, 10, WithMinWorkers(2))
+	assert.NoError(t, pool.Start())
+	defer pool.Stop()
+
+	assert.Equal(t, 2, pool.AddWorkers(2))
+	// Uno de los workers ociosos lo retiene el distribuidor esperando un job
+	assert.Eventually(t, func() bool { return pool.Idle() == 3 }, time.Second, 5*time.Millisecond)
+
+	scaler := newAutoscaler(testScalingPolicy(2, 4), pool)
+	for range 10 {
+		scaler.evaluate()
+	}
+
+	assert.Equal(t, 2, pool.Size())
+	var sizes [][2]int
+	for _, event := range scaler.Events() {
+		sizes = append(sizes, [2]int{event.From, event.To})
+	}
+	assert.Equal(t, [][2]int{{4, 3}, {3, 2}}, sizes)
+
+	// Con workers ociosos pero en el mínimo no hay nada que retirar
+	assert.Greater(t, pool.Idle(), 0)
+	assert.Nil(t, scaler.evaluate())
+	assert.Nil(t, scaler.evaluate())
+	assert.Equal(t, 2, pool.Size())
+}
+
+func TestAutoscalerIgnoresJobsWaitingForTheirKey(t *testing.T) {
+	pool := NewWorkerPool(4, 20, WithMinWorkers(1))
+	assert.NoError(t, pool.Start())
+	defer pool.Stop()
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	assert.NoError(t, pool.Submit(&orderedJob{testJob: newTestJob("first", func(context.Context) error {
+		close(running)
+		<-release
+		return nil
+	}), key: "offer:1"}))
+	<-running
+	for i := range 6 {
+		assert.NoError(t, pool.Submit(&orderedJob{testJob: newTestJob(fmt.Sprintf("next-%d", i), nil), key: "offer:1"}))
+	}
+	assert.Equal(t, 6, pool.Pending())
+
+	// Más workers no adelantarían ninguno de los que esperan su turno
+	scaler := newAutoscaler(testScalingPolicy(1, 4), pool)
+	for range 5 {
+		assert.Nil(t, scaler.evaluate())
+	}
+	assert.Equal(t, 1, pool.Size())
+}
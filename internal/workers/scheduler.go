@@ -0,0 +1,496 @@
+package worker
+
+import (
+	"container/heap"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// MissedRunPolicy define qué hacer con las ejecuciones de un cron que se
+// perdieron mientras el dispatcher estaba detenido
+type MissedRunPolicy int
+
+const (
+	// MissedRunSkip descarta las ejecuciones perdidas y espera la próxima
+	MissedRunSkip MissedRunPolicy = iota
+	// MissedRunCatchUpOnce ejecuta una sola vez si hubo ejecuciones perdidas
+	MissedRunCatchUpOnce
+	// MissedRunCatchUpAll ejecuta cada una de las ejecuciones perdidas
+	MissedRunCatchUpAll
+)
+
+const (
+	// missedRunTolerance es el atraso a partir del cual una ejecución se considera perdida
+	missedRunTolerance = time.Minute
+	// maxCatchUpRuns acota las ejecuciones recuperadas con MissedRunCatchUpAll
+	maxCatchUpRuns = 1000
+)
+
+// ScheduleStore persiste la última ejecución de cada cron para detectar
+// ejecuciones perdidas entre reinicios
+type ScheduleStore interface {
+	LastRun(name string) (time.Time, bool)
+	SetLastRun(name string, t time.Time) error
+}
+
+// CronOption configura parámetros opcionales de un cron
+type CronOption func(*cronEntry)
+
+// WithMissedRunPolicy define la política para ejecuciones perdidas (por defecto MissedRunSkip)
+func WithMissedRunPolicy(policy MissedRunPolicy) CronOption {
+	return func(e *cronEntry) {
+		e.missedPolicy = policy
+	}
+}
+
+// cronEntry es un job recurrente registrado en el scheduler
+type cronEntry struct {
+	name         string
+	spec         string
+	schedule     Schedule
+	job          Job
+	missedPolicy MissedRunPolicy
+	next         time.Time
+	// enqueuing indica que sus ejecuciones anteriores todavía esperan lugar en
+	// la cola. Mientras tanto next no avanza: la ejecución vencida se evalúa
+	// cuando terminan, según la política de ejecuciones perdidas.
+	enqueuing bool
+}
+
+// delayedJob es un job a encolar en un momento determinado
+type delayedJob struct {
+	at  time.Time
+	job Job
+	seq uint64
+}
+
+// DelayedJob es un job diferido que todavía no se encoló
+type DelayedJob struct {
+	Job Job
+	At  time.Time
+}
+
+// scheduler encola jobs diferidos y recurrentes en el dispatcher. Cada job
+// vencido se encola en su propia goroutine, así una cola llena no demora al
+// resto de los jobs programados.
+type scheduler struct {
+	submit   func(context.Context, Job) error
+	store    ScheduleStore
+	inflight sync.WaitGroup
+	// storeMu serializa las escrituras al store, que se hacen fuera de mu
+	storeMu sync.Mutex
+
+	mu      sync.Mutex
+	delayed delayedHeap
+	crons   map[string]*cronEntry
+	seq     uint64
+	running bool
+	wake    chan struct{}
+}
+
+func newScheduler(submit func(context.Context, Job) error, store ScheduleStore) *scheduler {
+	if store == nil {
+		store = NewMemoryScheduleStore()
+	}
+
+	return &scheduler{
+		submit: submit,
+		store:  store,
+		crons:  make(map[string]*cronEntry),
+		wake:   make(chan struct{}, 1),
+	}
+}
+
+// at programa un job para el momento indicado
+func (s *scheduler) at(job Job, at time.Time) {
+	s.mu.Lock()
+	s.seq++
+	heap.Push(&s.delayed, &delayedJob{at: at, job: job, seq: s.seq})
+	s.mu.Unlock()
+
+	s.notify()
+}
+
+// addCron registra un job recurrente
+func (s *scheduler) addCron(name, spec string, job Job, opts ...CronOption) error {
+	schedule, err := ParseSchedule(spec)
+	if err != nil {
+		return err
+	}
+
+	entry := &cronEntry{
+		name:     name,
+		spec:     spec,
+		schedule: schedule,
+		job:      job,
+	}
+	for _, opt := range opts {
+		opt(entry)
+	}
+
+	s.mu.Lock()
+	if _, exists := s.crons[name]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("cron job %q already scheduled", name)
+	}
+	if s.running {
+		s.initCron(entry, time.Now())
+	}
+	s.crons[name] = entry
+	s.mu.Unlock()
+
+	s.notify()
+	return nil
+}
+
+// removeCron elimina un job recurrente
+func (s *scheduler) removeCron(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.crons[name]; !exists {
+		return false
+	}
+	delete(s.crons, name)
+	return true
+}
+
+// initCron calcula la próxima ejecución a partir de la última persistida (requiere s.mu)
+func (s *scheduler) initCron(entry *cronEntry, now time.Time) {
+	if lastRun, ok := s.store.LastRun(entry.name); ok {
+		entry.next = entry.schedule.Next(lastRun)
+		return
+	}
+	entry.next = entry.schedule.Next(now)
+}
+
+// run es el loop del scheduler (usado con waitgroup.Go)
+func (s *scheduler) run(ctx context.Context) {
+	s.mu.Lock()
+	s.running = true
+	now := time.Now()
+	for _, entry := range s.crons {
+		s.initCron(entry, now)
+	}
+	s.mu.Unlock()
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		wait := s.fire(ctx, time.Now())
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+
+		select {
+		case <-timer.C:
+		case <-s.wake:
+		case <-ctx.Done():
+			s.mu.Lock()
+			s.running = false
+			s.mu.Unlock()
+			// ctx ya venció, así que los encolados pendientes terminan enseguida
+			s.inflight.Wait()
+			log.Println("Scheduler shutting down")
+			return
+		}
+	}
+}
+
+// fire encola todo lo que ya venció y devuelve cuánto esperar hasta el próximo vencimiento
+func (s *scheduler) fire(ctx context.Context, now time.Time) time.Duration {
+	var (
+		due     []*delayedJob
+		skipped = make(map[string]time.Time)
+	)
+
+	s.mu.Lock()
+	for s.delayed.Len() > 0 && !s.delayed.items[0].at.After(now) {
+		due = append(due, heap.Pop(&s.delayed).(*delayedJob))
+	}
+
+	for _, entry := range s.crons {
+		// Un cron que todavía encola sus ejecuciones anteriores se evalúa
+		// cuando terminan (enqueueCron despierta al loop)
+		if entry.enqueuing || entry.next.IsZero() || entry.next.After(now) {
+			continue
+		}
+
+		runs, last := s.dueRuns(entry, now)
+		if runs == 0 {
+			skipped[entry.name] = last
+			continue
+		}
+		entry.enqueuing = true
+		s.inflight.Go(func() { s.enqueueCron(ctx, entry, runs, last) })
+	}
+
+	next := time.Hour
+	if s.delayed.Len() > 0 {
+		next = min(next, s.delayed.items[0].at.Sub(now))
+	}
+	for _, entry := range s.crons {
+		if !entry.enqueuing && !entry.next.IsZero() {
+			next = min(next, entry.next.Sub(now))
+		}
+	}
+	s.mu.Unlock()
+
+	// Persistir fuera de s.mu: el store puede escribir a disco
+	for name, last := range skipped {
+		s.setLastRun(name, last)
+	}
+	for _, entry := range due {
+		s.inflight.Go(func() { s.enqueueDelayed(ctx, entry) })
+	}
+
+	return max(next, 0)
+}
+
+// enqueueDelayed encola un job diferido. Si el scheduler se detiene mientras
+// espera lugar en la cola, lo devuelve a los diferidos para que Drain lo informe.
+func (s *scheduler) enqueueDelayed(ctx context.Context, entry *delayedJob) {
+	err := s.submit(ctx, entry.job)
+	if err == nil {
+		return
+	}
+
+	if ctx.Err() != nil {
+		s.mu.Lock()
+		heap.Push(&s.delayed, entry)
+		s.mu.Unlock()
+		return
+	}
+	log.Printf("Scheduler: failed to enqueue job %s: %v", entry.job.Name(), err)
+}
+
+// enqueueCron encola las ejecuciones vencidas de un cron y, solo si se
+// encolaron, persiste last como su última ejecución. Si alguna falla, la
+// política de ejecuciones perdidas la recupera en el próximo arranque. Al
+// terminar despierta al loop por si venció otra ejecución mientras tanto.
+func (s *scheduler) enqueueCron(ctx context.Context, entry *cronEntry, runs int, last time.Time) {
+	defer func() {
+		s.mu.Lock()
+		entry.enqueuing = false
+		s.mu.Unlock()
+		s.notify()
+	}()
+
+	for range runs {
+		if err := s.submit(ctx, entry.job); err != nil {
+			log.Printf("Scheduler: failed to enqueue cron job %s: %v", entry.name, err)
+			return
+		}
+	}
+
+	s.setLastRun(entry.name, last)
+}
+
+// setLastRun persiste la última ejecución de un cron, salvo que ya haya una
+// posterior (fire y enqueueCron pueden persistir en cualquier orden)
+func (s *scheduler) setLastRun(name string, last time.Time) {
+	s.storeMu.Lock()
+	defer s.storeMu.Unlock()
+
+	if current, ok := s.store.LastRun(name); ok && !last.After(current) {
+		return
+	}
+	if err := s.store.SetLastRun(name, last); err != nil {
+		log.Printf("Scheduler: failed to persist last run of %s: %v", name, err)
+	}
+}
+
+// dueRuns calcula cuántas veces ejecutar un cron vencido según su política
+// de ejecuciones perdidas y el momento a persistir como última ejecución, y
+// avanza su próxima ejecución más allá de now (requiere s.mu)
+func (s *scheduler) dueRuns(entry *cronEntry, now time.Time) (int, time.Time) {
+	pending := 0
+	last := entry.next
+	next := entry.next
+	skipped := false
+	for ; !next.IsZero() && !next.After(now); next = entry.schedule.Next(next) {
+		if pending == maxCatchUpRuns {
+			skipped = true
+			break
+		}
+		pending++
+		last = next
+	}
+
+	// Solo la ejecución más reciente puede estar en horario
+	onTime := !skipped && now.Sub(last) <= missedRunTolerance
+	missed := pending
+	if onTime {
+		missed--
+	}
+
+	if skipped {
+		// Descartar el resto y seguir desde ahora, así las ejecuciones
+		// perdidas se procesan una sola vez
+		log.Printf("Scheduler: cron %s missed more than %d runs, skipping the rest", entry.name, maxCatchUpRuns)
+		next = entry.schedule.Next(now)
+		last = now
+	} else if missed > 0 {
+		log.Printf("Scheduler: cron %s missed %d runs", entry.name, missed)
+	}
+	entry.next = next
+
+	switch entry.missedPolicy {
+	case MissedRunCatchUpAll:
+		return pending, last
+	case MissedRunCatchUpOnce:
+		return 1, last
+	default:
+		if onTime {
+			return 1, last
+		}
+		return 0, last
+	}
+}
+
+// takeDelayed quita y devuelve los jobs diferidos pendientes
+func (s *scheduler) takeDelayed() []DelayedJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pending := make([]DelayedJob, 0, s.delayed.Len())
+	for s.delayed.Len() > 0 {
+		entry := heap.Pop(&s.delayed).(*delayedJob)
+		pending = append(pending, DelayedJob{Job: entry.job, At: entry.at})
+	}
+	return pending
+}
+
+// stats devuelve la cantidad de jobs diferidos y de crons registrados
+func (s *scheduler) stats() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.delayed.Len(), len(s.crons)
+}
+
+// notify despierta al loop para recalcular el próximo vencimiento
+func (s *scheduler) notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// delayedHeap ordena los jobs diferidos por momento de ejecución
+type delayedHeap struct {
+	items []*delayedJob
+}
+
+func (h delayedHeap) Len() int { return len(h.items) }
+
+func (h delayedHeap) Less(i, j int) bool {
+	if h.items[i].at.Equal(h.items[j].at) {
+		return h.items[i].seq < h.items[j].seq
+	}
+	return h.items[i].at.Before(h.items[j].at)
+}
+
+func (h delayedHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *delayedHeap) Push(x interface{}) { h.items = append(h.items, x.(*delayedJob)) }
+
+func (h *delayedHeap) Pop() interface{} {
+	old := h.items
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	h.items = old[:n-1]
+	return item
+}
+
+// MemoryScheduleStore guarda las últimas ejecuciones en memoria (no detecta
+// ejecuciones perdidas entre reinicios del proceso)
+type MemoryScheduleStore struct {
+	mu   sync.RWMutex
+	runs map[string]time.Time
+}
+
+// NewMemoryScheduleStore crea un store de ejecuciones en memoria
+func NewMemoryScheduleStore() *MemoryScheduleStore {
+	return &MemoryScheduleStore{runs: make(map[string]time.Time)}
+}
+
+// LastRun implementa ScheduleStore
+func (s *MemoryScheduleStore) LastRun(name string) (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	t, ok := s.runs[name]
+	return t, ok
+}
+
+// SetLastRun implementa ScheduleStore
+func (s *MemoryScheduleStore) SetLastRun(name string, t time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.runs[name] = t
+	return nil
+}
+
+// FileScheduleStore persiste las últimas ejecuciones en un archivo JSON
+type FileScheduleStore struct {
+	path string
+	mem  *MemoryScheduleStore
+}
+
+// NewFileScheduleStore crea un store respaldado por el archivo indicado,
+// cargando su contenido si ya existe
+func NewFileScheduleStore(path string) (*FileScheduleStore, error) {
+	store := &FileScheduleStore{path: path, mem: NewMemoryScheduleStore()}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read schedule store: %w", err)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &store.mem.runs); err != nil {
+			return nil, fmt.Errorf("failed to decode schedule store: %w", err)
+		}
+	}
+
+	return store, nil
+}
+
+// LastRun implementa ScheduleStore
+func (s *FileScheduleStore) LastRun(name string) (time.Time, bool) {
+	return s.mem.LastRun(name)
+}
+
+// SetLastRun implementa ScheduleStore reescribiendo el archivo de forma atómica
+func (s *FileScheduleStore) SetLastRun(name string, t time.Time) error {
+	s.mem.mu.Lock()
+	defer s.mem.mu.Unlock()
+
+	s.mem.runs[name] = t
+
+	data, err := json.Marshal(s.mem.runs)
+	if err != nil {
+		return fmt.Errorf("failed to encode schedule store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create schedule store directory: %w", err)
+	}
+
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write schedule store: %w", err)
+	}
+	return os.Rename(tmpPath, s.path)
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDueRunsAdvancesPastNow(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name   string
+		policy MissedRunPolicy
+		missed int
+		late   time.Duration
+		runs   int
+	}{
+		{"skip on time", MissedRunSkip, 0, time.Second, 1},
+		{"skip after downtime", MissedRunSkip, 10, 2 * time.Minute, 0},
+		{"catch up once", MissedRunCatchUpOnce, 10, 2 * time.Minute, 1},
+		{"catch up once after long downtime", MissedRunCatchUpOnce, 5 * maxCatchUpRuns, time.Second, 1},
+		{"catch up all", MissedRunCatchUpAll, 10, 2 * time.Minute, 11},
+		{"catch up all after long downtime", MissedRunCatchUpAll, 5 * maxCatchUpRuns, time.Second, maxCatchUpRuns},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newScheduler(nil, nil)
+			schedule, err := ParseSchedule("@every 1h")
+			assert.NoError(t, err)
+
+			entry := &cronEntry{
+				name:         "test",
+				schedule:     schedule,
+				missedPolicy: tt.policy,
+				next:         now.Add(-time.Duration(tt.missed)*time.Hour - tt.late),
+			}
+
+			runs, _ := s.dueRuns(entry, now)
+			assert.Equal(t, tt.runs, runs)
+			assert.True(t, entry.next.After(now), "next run %v is not after now", entry.next)
+		})
+	}
+}
+
+func TestSchedulerPersistsLastRunOnlyWhenEnqueued(t *testing.T) {
+	now := time.Now()
+	store := NewMemoryScheduleStore()
+	s := newScheduler(func(context.Context, Job) error {
+		return errors.New("queue full")
+	}, store)
+
+	schedule, err := ParseSchedule("@every 1m")
+	assert.NoError(t, err)
+	s.crons["test"] = &cronEntry{name: "test", schedule: schedule, next: now.Add(-time.Second)}
+
+	s.fire(context.Background(), now)
+	s.inflight.Wait()
+
+	_, ok := store.LastRun("test")
+	assert.False(t, ok)
+
+	s.submit = func(context.Context, Job) error { return nil }
+	s.crons["test"].next = now.Add(-time.Second)
+	s.fire(context.Background(), now)
+	s.inflight.Wait()
+
+	_, ok = store.LastRun("test")
+	assert.True(t, ok)
+}
+
+func TestSchedulerFullQueueDoesNotBlockOtherJobs(t *testing.T) {
+	blocked := make(chan struct{})
+	enqueued := make(chan string, 1)
+	s := newScheduler(func(ctx context.Context, job Job) error {
+		if job.Name() == "blocked" {
+			<-blocked
+			return nil
+		}
+		enqueued <- job.Name()
+		return nil
+	}, nil)
+	defer close(blocked)
+
+	now := time.Now()
+	s.at(newTestJob("blocked", nil), now.Add(-time.Second))
+	s.at(newTestJob("other", nil), now.Add(-time.Second))
+	s.fire(context.Background(), now)
+
+	select {
+	case name := <-enqueued:
+		assert.Equal(t, "other", name)
+	case <-time.After(time.Second):
+		t.Fatal("scheduler blocked on a full queue")
+	}
+}
+
+func TestSchedulerKeepsRunDueWhileEnqueuing(t *testing.T) {
+	release := make(chan struct{})
+	submitted := make(chan struct{}, 2)
+	s := newScheduler(func(context.Context, Job) error {
+		submitted <- struct{}{}
+		<-release
+		return nil
+	}, nil)
+
+	schedule, err := ParseSchedule("@every 1m")
+	assert.NoError(t, err)
+	now := time.Now().Truncate(time.Second)
+	s.crons["test"] = &cronEntry{name: "test", job: newTestJob("test", nil), schedule: schedule, next: now}
+
+	s.fire(context.Background(), now)
+	<-submitted
+
+	// La ejecución siguiente vence mientras la anterior espera lugar en la
+	// cola: no se descarta, queda pendiente
+	due := now.Add(time.Minute)
+	s.fire(context.Background(), due)
+	s.mu.Lock()
+	assert.Equal(t, due, s.crons["test"].next)
+	s.mu.Unlock()
+
+	close(release)
+	s.inflight.Wait()
+	s.fire(context.Background(), due.Add(time.Second))
+	select {
+	case <-submitted:
+	case <-time.After(time.Second):
+		t.Fatal("run that came due while enqueuing was lost")
+	}
+	s.inflight.Wait()
+}
@@ -0,0 +1,200 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultHistoryTable es la tabla que usa SQLHistoryStore si no se indica otra
+const DefaultHistoryTable = "job_history"
+
+// sqlHistoryTimeout es el tiempo máximo de cada operación contra la base
+const sqlHistoryTimeout = 10 * time.Second
+
+// SQLHistoryOption configura parámetros opcionales del SQLHistoryStore
+type SQLHistoryOption func(*SQLHistoryStore)
+
+// WithHistoryTable define la tabla del historial (por defecto DefaultHistoryTable)
+func WithHistoryTable(table string) SQLHistoryOption {
+	return func(s *SQLHistoryStore) {
+		s.table = table
+	}
+}
+
+// WithDollarPlaceholders usa parámetros $1, $2... (PostgreSQL) en lugar de ?
+// (MySQL, SQLite)
+func WithDollarPlaceholders() SQLHistoryOption {
+	return func(s *SQLHistoryStore) {
+		s.dollar = true
+	}
+}
+
+// SQLHistoryStore es un HistoryStore sobre database/sql. No depende de un
+// driver en particular: recibe la conexión ya abierta, y el driver debe poder
+// leer columnas TIMESTAMP como time.Time (en MySQL, con parseTime=true).
+type SQLHistoryStore struct {
+	db     *sql.DB
+	table  string
+	dollar bool
+}
+
+// NewSQLHistoryStore crea un historial sobre la conexión indicada. La tabla
+// se crea con CreateTable.
+func NewSQLHistoryStore(db *sql.DB, opts ...SQLHistoryOption) *SQLHistoryStore {
+	store := &SQLHistoryStore{db: db, table: DefaultHistoryTable}
+	for _, opt := range opts {
+		opt(store)
+	}
+	return store
+}
+
+// CreateTable crea la tabla del historial si no existe. El ID no es clave
+// primaria porque un job recuperado de una cola durable puede terminar más de
+// una vez; conviene además indexar finished_at y job_name.
+func (s *SQLHistoryStore) CreateTable(ctx context.Context) error {
+	query := `CREATE TABLE IF NOT EXISTS ` + s.table + ` (
+	id VARCHAR(64) NOT NULL,
+	job_name VARCHAR(255) NOT NULL,
+	queue VARCHAR(255) NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	priority INTEGER NOT NULL,
+	request_id VARCHAR(255) NOT NULL,
+	attempts INTEGER NOT NULL,
+	error TEXT NOT NULL,
+	enqueued_at TIMESTAMP NOT NULL,
+	finished_at TIMESTAMP NOT NULL,
+	duration_ns BIGINT NOT NULL
+)`
+	if _, err := s.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create history table: %w", err)
+	}
+	return nil
+}
+
+// Add implementa HistoryStore
+func (s *SQLHistoryStore) Add(entry HistoryEntry) error {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlHistoryTimeout)
+	defer cancel()
+
+	query := `INSERT INTO ` + s.table + ` (id, job_name, queue, status, priority, request_id,
+	attempts, error, enqueued_at, finished_at, duration_ns) VALUES (` + s.placeholders(1, 11) + `)`
+	_, err := s.db.ExecContext(ctx, query,
+		entry.ID, entry.JobName, entry.Queue, string(entry.Status), entry.Priority, entry.RequestID,
+		entry.Attempts, entry.Error, entry.EnqueuedAt.UTC(), entry.FinishedAt.UTC(), int64(entry.Duration),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert history entry: %w", err)
+	}
+	return nil
+}
+
+// Query implementa HistoryStore
+func (s *SQLHistoryStore) Query(query HistoryQuery) ([]HistoryEntry, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlHistoryTimeout)
+	defer cancel()
+
+	var (
+		conditions []string
+		args       []any
+	)
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, s.placeholder(len(args))))
+	}
+
+	if query.ID != "" {
+		where("id = %s", query.ID)
+	}
+	if query.JobName != "" {
+		where("job_name LIKE %s ESCAPE '!'", escapeLike(query.JobName)+"%")
+	}
+	if query.Status != "" {
+		where("status = %s", string(query.Status))
+	}
+	if !query.Since.IsZero() {
+		where("finished_at >= %s", query.Since.UTC())
+	}
+	if !query.Until.IsZero() {
+		where("finished_at < %s", query.Until.UTC())
+	}
+
+	statement := `SELECT id, job_name, queue, status, priority, request_id, attempts, error,
+	enqueued_at, finished_at, duration_ns FROM ` + s.table
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	statement += " ORDER BY finished_at DESC"
+	if query.Limit > 0 {
+		statement += fmt.Sprintf(" LIMIT %d", query.Limit)
+	}
+
+	rows, err := s.db.QueryContext(ctx, statement, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]HistoryEntry, 0)
+	for rows.Next() {
+		var (
+			entry    HistoryEntry
+			status   string
+			duration int64
+		)
+		err := rows.Scan(&entry.ID, &entry.JobName, &entry.Queue, &status, &entry.Priority, &entry.RequestID,
+			&entry.Attempts, &entry.Error, &entry.EnqueuedAt, &entry.FinishedAt, &duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read history entry: %w", err)
+		}
+		entry.Status = JobStatus(status)
+		entry.Duration = time.Duration(duration)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	return entries, nil
+}
+
+// Prune implementa HistoryStore
+func (s *SQLHistoryStore) Prune(before time.Time) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), sqlHistoryTimeout)
+	defer cancel()
+
+	result, err := s.db.ExecContext(ctx, `DELETE FROM `+s.table+` WHERE finished_at < `+s.placeholder(1), before.UTC())
+	if err != nil {
+		return 0, fmt.Errorf("failed to prune history: %w", err)
+	}
+
+	pruned, err := result.RowsAffected()
+	if err != nil {
+		// El driver no informa cuántas filas eliminó
+		return 0, nil
+	}
+	return int(pruned), nil
+}
+
+// placeholder devuelve el parámetro número n en la sintaxis del driver
+func (s *SQLHistoryStore) placeholder(n int) string {
+	if s.dollar {
+		return fmt.Sprintf("$%d", n)
+	}
+	return "?"
+}
+
+// placeholders devuelve los parámetros de from a to separados por comas
+func (s *SQLHistoryStore) placeholders(from, to int) string {
+	params := make([]string, 0, to-from+1)
+	for n := from; n <= to; n++ {
+		params = append(params, s.placeholder(n))
+	}
+	return strings.Join(params, ", ")
+}
+
+// escapeLike escapa los comodines de LIKE usando ! como carácter de escape
+func escapeLike(value string) string {
+	return strings.NewReplacer("!", "!!", "%", "!%", "_", "!_").Replace(value)
+}
@@ -0,0 +1,494 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// DefaultJobHistory es la cantidad de jobs terminados que se conservan para consultar su estado
+const DefaultJobHistory = 10000
+
+// ErrJobCancelled es el error de los jobs cancelados con Cancel
+var ErrJobCancelled = errors.New("job cancelled")
+
+// JobStatus es el estado de un job encolado en el pool
+type JobStatus string
+
+const (
+	// JobQueued espera en la cola a un worker libre
+	JobQueued JobStatus = "queued"
+	// JobRunning está siendo ejecutado por un worker
+	JobRunning JobStatus = "running"
+	// JobSucceeded terminó con éxito
+	JobSucceeded JobStatus = "succeeded"
+	// JobFailed terminó con error luego de agotar sus intentos
+	JobFailed JobStatus = "failed"
+	// JobCancelled se canceló antes de terminar
+	JobCancelled JobStatus = "cancelled"
+)
+
+// Done indica si el estado es final
+func (s JobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
+
+// JobInfo describe el estado de un job encolado
+type JobInfo struct {
+	ID         string    `json:"id"`
+	JobName    string    `json:"job_name"`
+	Status     JobStatus `json:"status"`
+	Priority   int       `json:"priority"`
+	RequestID  string    `json:"request_id,omitempty"`
+	WorkerID   int       `json:"worker_id,omitempty"`
+	Attempts   int       `json:"attempts,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	EnqueuedAt time.Time `json:"enqueued_at"`
+	StartedAt  time.Time `json:"started_at,omitzero"`
+	FinishedAt time.Time `json:"finished_at,omitzero"`
+	// Progress es el último avance informado con types.ReportProgress
+	Progress types.Progress `json:"progress,omitzero"`
+}
+
+// trackedJob es el estado de un job desde que se encola hasta que termina
+type trackedJob struct {
+	mu              sync.Mutex
+	key             string
+	info            JobInfo
+	result          types.JobResult
+	cancel          context.CancelCauseFunc
+	cancelRequested bool
+	done            chan struct{}
+	// changed se cierra y se reemplaza en cada cambio de estado o de avance
+	changed chan struct{}
+}
+
+// snapshot devuelve una copia del estado del job
+func (t *trackedJob) snapshot() JobInfo {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.info
+}
+
+// notify despierta a los que observan el job (requiere t.mu)
+func (t *trackedJob) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// keyExpiry es el vencimiento de una clave de idempotencia de un job exitoso
+type keyExpiry struct {
+	key string
+	job *trackedJob
+	at  time.Time
+}
+
+// jobTracker guarda el estado de los jobs en vuelo y de los últimos terminados,
+// y el índice de claves de idempotencia para deduplicar
+type jobTracker struct {
+	mu       sync.Mutex
+	jobs     map[string]*trackedJob
+	finished []string
+	history  int
+	// keys indexa los jobs en vuelo, y los exitosos durante keyWindow, por clave
+	keys      map[string]*trackedJob
+	expiring  []keyExpiry
+	keyWindow time.Duration
+}
+
+func newJobTracker(history int, keyWindow time.Duration) *jobTracker {
+	return &jobTracker{
+		jobs:      make(map[string]*trackedJob),
+		history:   history,
+		keys:      make(map[string]*trackedJob),
+		keyWindow: keyWindow,
+	}
+}
+
+// idempotencyKey devuelve la clave de idempotencia del job ("" si no declara una)
+func idempotencyKey(job Job) string {
+	if idempotent, ok := job.(types.IdempotentJob); ok {
+		return idempotent.IdempotencyKey()
+	}
+	return ""
+}
+
+// track registra un item recién encolado. Si el job declara una clave de
+// idempotencia y ya hay un job en vuelo (o exitoso dentro de la ventana) con
+// esa clave, devuelve ese job y true sin registrar el nuevo.
+func (t *jobTracker) track(item *QueueItem) (*trackedJob, bool) {
+	key := idempotencyKey(item.Job)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if key != "" {
+		t.expireKeys(time.Now())
+		if existing, ok := t.keys[key]; ok {
+			return existing, true
+		}
+	}
+	return t.add(item, key), false
+}
+
+// adopt registra con su propio ID un item recuperado de una cola durable, sin
+// deduplicar: ya estaba encolado antes del reinicio. Su clave solo se toma si
+// está libre, así los envíos nuevos se deduplican contra él.
+func (t *jobTracker) adopt(item *QueueItem) *trackedJob {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if job, ok := t.jobs[item.ID]; ok {
+		return job
+	}
+
+	key := idempotencyKey(item.Job)
+	if _, taken := t.keys[key]; taken {
+		key = ""
+	}
+	return t.add(item, key)
+}
+
+// add registra el item como encolado y le asigna la clave (requiere t.mu)
+func (t *jobTracker) add(item *QueueItem, key string) *trackedJob {
+	job := &trackedJob{
+		key: key,
+		info: JobInfo{
+			ID:         item.ID,
+			JobName:    item.Job.Name(),
+			Status:     JobQueued,
+			Priority:   item.Priority,
+			RequestID:  item.RequestID,
+			EnqueuedAt: item.EnqueuedAt,
+		},
+		done:    make(chan struct{}),
+		changed: make(chan struct{}),
+	}
+
+	t.jobs[item.ID] = job
+	if key != "" {
+		t.keys[key] = job
+	}
+	return job
+}
+
+// expireKeys libera las claves de los jobs exitosos cuya ventana venció (requiere t.mu)
+func (t *jobTracker) expireKeys(now time.Time) {
+	for len(t.expiring) > 0 && !now.Before(t.expiring[0].at) {
+		expired := t.expiring[0]
+		t.expiring = t.expiring[1:]
+		if t.keys[expired.key] == expired.job {
+			delete(t.keys, expired.key)
+		}
+	}
+}
+
+// releaseKey libera la clave del job si sigue apuntando a él (requiere t.mu)
+func (t *jobTracker) releaseKey(job *trackedJob) {
+	if job.key != "" && t.keys[job.key] == job {
+		delete(t.keys, job.key)
+	}
+}
+
+// forget descarta un item que no llegó a encolarse
+func (t *jobTracker) forget(id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if job, ok := t.jobs[id]; ok {
+		t.releaseKey(job)
+		delete(t.jobs, id)
+	}
+}
+
+// get busca un job por su ID
+func (t *jobTracker) get(id string) (*trackedJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	job, ok := t.jobs[id]
+	return job, ok
+}
+
+// start marca el job como en ejecución y guarda la función para cancelarlo.
+// Devuelve false si el job se canceló mientras esperaba en la cola.
+func (t *jobTracker) start(item *QueueItem, workerID int, cancel context.CancelCauseFunc) bool {
+	job, ok := t.get(item.ID)
+	if !ok {
+		// Ya no figura (por ejemplo, lo descartó el historial): se registra
+		// con su propio ID para que finish lo encuentre
+		job = t.adopt(item)
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.cancelRequested {
+		return false
+	}
+
+	job.info.Status = JobRunning
+	job.info.WorkerID = workerID
+	job.info.StartedAt = time.Now()
+	job.cancel = cancel
+	job.notify()
+	return true
+}
+
+// progress registra el avance informado por un job en ejecución
+func (t *jobTracker) progress(id string, progress types.Progress) {
+	job, ok := t.get(id)
+	if !ok {
+		return
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	if job.info.Status != JobRunning {
+		return
+	}
+	job.info.Progress = progress
+	job.notify()
+}
+
+// finish registra el resultado final del job y descarta los terminados más
+// antiguos si se supera el historial
+func (t *jobTracker) finish(id string, result types.JobResult) {
+	job, ok := t.get(id)
+	if !ok {
+		return
+	}
+
+	job.mu.Lock()
+	if job.info.Status.Done() {
+		job.mu.Unlock()
+		return
+	}
+	job.info.Status = resultStatus(result)
+	if result.Error != nil {
+		job.info.Error = result.Error.Error()
+	}
+	job.info.Attempts = result.Attempts
+	job.info.FinishedAt = result.Timestamp
+	job.result = result
+	job.cancel = nil
+	close(job.done)
+	job.notify()
+	succeeded := job.info.Status == JobSucceeded
+	job.mu.Unlock()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Solo los exitosos retienen su clave: un fallido puede reintentarse o reencolarse
+	if job.key != "" {
+		if succeeded && t.keyWindow > 0 {
+			t.expiring = append(t.expiring, keyExpiry{key: job.key, job: job, at: time.Now().Add(t.keyWindow)})
+		} else {
+			t.releaseKey(job)
+		}
+	}
+
+	t.finished = append(t.finished, id)
+	for len(t.finished) > t.history {
+		delete(t.jobs, t.finished[0])
+		t.finished = t.finished[1:]
+	}
+}
+
+// list devuelve los jobs que cumplan el filtro ordenados por momento de encolado
+func (t *jobTracker) list(filter func(JobInfo) bool) []JobInfo {
+	t.mu.Lock()
+	jobs := make([]*trackedJob, 0, len(t.jobs))
+	for _, job := range t.jobs {
+		jobs = append(jobs, job)
+	}
+	t.mu.Unlock()
+
+	infos := make([]JobInfo, 0)
+	for _, job := range jobs {
+		info := job.snapshot()
+		if filter == nil || filter(info) {
+			infos = append(infos, info)
+		}
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].EnqueuedAt.Before(infos[j].EnqueuedAt)
+	})
+	return infos
+}
+
+// JobHandle permite consultar, esperar y cancelar un job encolado
+type JobHandle struct {
+	pool *Pool
+	job  *trackedJob
+}
+
+// ID devuelve el identificador con el que se rastrea el job
+func (h *JobHandle) ID() string {
+	return h.job.info.ID
+}
+
+// Status devuelve el estado actual del job
+func (h *JobHandle) Status() JobInfo {
+	return h.job.snapshot()
+}
+
+// Cancel cancela el job si todavía no terminó
+func (h *JobHandle) Cancel() error {
+	return h.pool.Cancel(h.ID())
+}
+
+// Done devuelve un canal que se cierra cuando el job termina
+func (h *JobHandle) Done() <-chan struct{} {
+	return h.job.done
+}
+
+// Watch recorre el estado del job cada vez que cambia o informa avance
+func (h *JobHandle) Watch(ctx context.Context) iter.Seq[JobInfo] {
+	return h.job.watch(ctx)
+}
+
+// Wait bloquea hasta que el job termine o se cancele ctx
+func (h *JobHandle) Wait(ctx context.Context) (types.JobResult, error) {
+	select {
+	case <-h.job.done:
+		h.job.mu.Lock()
+		defer h.job.mu.Unlock()
+		return h.job.result, nil
+	case <-ctx.Done():
+		return types.JobResult{}, ctx.Err()
+	}
+}
+
+// Status devuelve el estado de un job por su ID
+func (p *Pool) Status(id string) (JobInfo, error) {
+	job, ok := p.jobs.get(id)
+	if !ok {
+		return JobInfo{}, fmt.Errorf("job %s not found", id)
+	}
+	return job.snapshot(), nil
+}
+
+// Watch recorre el estado de un job cada vez que cambia de estado o informa
+// avance, empezando por el actual, hasta que termine o venza ctx. Las
+// actualizaciones que llegan mientras el consumidor procesa la anterior se
+// unifican: siempre se entrega la más reciente.
+func (p *Pool) Watch(ctx context.Context, id string) (iter.Seq[JobInfo], error) {
+	job, ok := p.jobs.get(id)
+	if !ok {
+		return nil, fmt.Errorf("job %s not found", id)
+	}
+	return job.watch(ctx), nil
+}
+
+// watch recorre las actualizaciones del job hasta que termine o venza ctx
+func (t *trackedJob) watch(ctx context.Context) iter.Seq[JobInfo] {
+	return func(yield func(JobInfo) bool) {
+		for {
+			t.mu.Lock()
+			info := t.info
+			changed := t.changed
+			t.mu.Unlock()
+
+			if !yield(info) || info.Status.Done() {
+				return
+			}
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// Cancel cancela un job: si está en la cola lo quita, y si está en ejecución
+// cancela su contexto. Devuelve error si el job no existe o ya terminó.
+func (p *Pool) Cancel(id string) error {
+	job, ok := p.jobs.get(id)
+	if !ok {
+		return fmt.Errorf("job %s not found", id)
+	}
+
+	job.mu.Lock()
+	if job.info.Status.Done() {
+		job.mu.Unlock()
+		return fmt.Errorf("job %s already %s", id, job.info.Status)
+	}
+	job.cancelRequested = true
+	cancel := job.cancel
+	job.mu.Unlock()
+
+	if cancel != nil {
+		cancel(ErrJobCancelled)
+		return nil
+	}
+
+	// Si el dispatcher ya lo extrajo, el worker lo descarta al verlo cancelado
+	if item, removed := p.jobQueue.Remove(id); removed {
+		p.cancelPending(item)
+		p.advanceKey(item)
+	} else if item, removed := p.held.remove(id); removed {
+		p.cancelTaken(item)
+	} else if item, removed := p.throttled.remove(id); removed {
+		p.cancelTaken(item)
+	} else if item, removed := p.ordering.remove(id); removed {
+		// Con una cola durable se persistió al retenerlo
+		if err := p.jobQueue.Ack(item); err != nil {
+			log.Printf("Worker pool: failed to ack job %s: %v", item.Job.Name(), err)
+		}
+		p.cancelPending(item)
+	} else if item, removed := p.removeSpilled(id); removed {
+		p.cancelPending(item)
+		p.advanceKey(item)
+	}
+	return nil
+}
+
+// cancelTaken cancela un item que salió de la cola sin confirmarse, retenido
+// por una pausa o por su límite de tasa
+func (p *Pool) cancelTaken(item *QueueItem) {
+	if err := p.jobQueue.Ack(item); err != nil {
+		log.Printf("Worker pool: failed to ack job %s: %v", item.Job.Name(), err)
+	}
+	p.cancelPending(item)
+	p.advanceKey(item)
+}
+
+// cancelPending registra como cancelado un item que nunca llegó a un worker y
+// se lo informa a su canal de respuesta
+func (p *Pool) cancelPending(item *QueueItem) {
+	result := types.JobResult{
+		JobID:     item.ID,
+		JobName:   item.Job.Name(),
+		Error:     ErrJobCancelled,
+		Timestamp: time.Now(),
+	}
+	p.jobs.finish(item.ID, result)
+	p.recordHistory(item, result)
+	p.metrics.cancelled(item)
+	p.release()
+
+	// Quien espera una respuesta por job también recibe la de los cancelados;
+	// Cancel no espera a que la lea
+	if responder, ok := item.Job.(types.JobWithResponse); ok && responder.ResponseChannel() != nil {
+		go p.respond(responder, result, p.timeout(responder))
+	}
+}
+
+// InFlight devuelve los jobs encolados o en ejecución
+func (p *Pool) InFlight() []JobInfo {
+	return p.jobs.list(func(info JobInfo) bool {
+		return !info.Status.Done()
+	})
+}
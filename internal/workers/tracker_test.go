@@ -0,0 +1,166 @@
+package worker
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// waitStatus espera a que el job llegue al estado indicado
+func waitStatus(t *testing.T, status func() JobStatus, want JobStatus) {
+	t.Helper()
+	assert.Eventually(t, func() bool { return status() == want }, 2*time.Second, 5*time.Millisecond)
+}
+
+func TestCancel(t *testing.T) {
+	tests := []struct {
+		name    string
+		paused  bool
+		block   bool
+		finish  bool
+		wantErr bool
+	}{
+		{"queued job", true, false, false, false},
+		{"running job", false, true, false, false},
+		{"finished job", false, false, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+			if tt.paused {
+				d.Pause()
+			}
+
+			started := make(chan struct{})
+			handle, err := d.Submit(newTestJob("job", func(ctx context.Context) error {
+				close(started)
+				if tt.block {
+					<-ctx.Done()
+					return ctx.Err()
+				}
+				return nil
+			}))
+			assert.NoError(t, err)
+
+			if tt.block {
+				<-started
+			}
+			if tt.finish {
+				waitStatus(t, func() JobStatus { return handle.Status().Status }, JobSucceeded)
+			}
+
+			err = d.Cancel(handle.ID())
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			waitStatus(t, func() JobStatus { return handle.Status().Status }, JobCancelled)
+			assert.Equal(t, 0, d.workerPool.Pending())
+		})
+	}
+
+	t.Run("unknown job", func(t *testing.T) {
+		d := NewDispatcher(context.Background(), 1, 10)
+		assert.NoError(t, d.Start())
+		defer d.Stop()
+		assert.Error(t, d.Cancel("missing"))
+	})
+}
+
+func TestIdempotentSubmit(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	d.Pause()
+
+	first, err := d.Submit(&serialJob{testJob: newTestJob("a", nil), key: "k"})
+	assert.NoError(t, err)
+	second, err := d.Submit(&serialJob{testJob: newTestJob("b", nil), key: "k"})
+	assert.NoError(t, err)
+	other, err := d.Submit(&serialJob{testJob: newTestJob("c", nil), key: "other"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, first.ID(), second.ID())
+	assert.NotEqual(t, first.ID(), other.ID())
+	assert.Equal(t, 2, d.workerPool.Pending())
+
+	d.Resume()
+	waitStatus(t, func() JobStatus { return first.Status().Status }, JobSucceeded)
+
+	// Sin ventana de idempotencia la clave se libera al terminar
+	third, err := d.Submit(&serialJob{testJob: newTestJob("d", nil), key: "k"})
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID(), third.ID())
+}
+
+func TestRecoveredJobsAreTracked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "queue.log")
+	registry := newTestRegistry()
+	queue, err := NewFileQueue(path, 10, registry)
+	assert.NoError(t, err)
+
+	// Dos jobs con la misma clave persistidos antes del reinicio y uno sin clave
+	items := []*QueueItem{
+		newQueueItem(&serialJob{testJob: newTestJob("a", nil), key: "k"}),
+		newQueueItem(&serialJob{testJob: newTestJob("b", nil), key: "k"}),
+		newQueueItem(&serialJob{testJob: newTestJob("c", nil)}),
+	}
+	for _, item := range items {
+		assert.NoError(t, queue.Push(context.Background(), item))
+	}
+	assert.NoError(t, queue.Close())
+
+	reopened, err := NewFileQueue(path, 10, registry)
+	assert.NoError(t, err)
+	pool := NewWorkerPool(1, 10, WithQueue(reopened))
+	pool.Pause()
+	assert.NoError(t, pool.Start())
+	defer pool.Stop()
+
+	for _, item := range items {
+		info, err := pool.Status(item.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, JobQueued, info.Status)
+	}
+	assert.NoError(t, pool.Cancel(items[2].ID))
+
+	pool.Resume()
+	for _, item := range items[:2] {
+		waitStatus(t, func() JobStatus {
+			info, _ := pool.Status(item.ID)
+			return info.Status
+		}, JobSucceeded)
+	}
+	info, err := pool.Status(items[2].ID)
+	assert.NoError(t, err)
+	assert.Equal(t, JobCancelled, info.Status)
+}
+
+func TestCancelledJobResponds(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	d.Pause()
+
+	response := make(chan types.JobResult)
+	handle, err := d.Submit(&responseJob{testJob: newTestJob("job", nil), id: "row-1", response: response})
+	assert.NoError(t, err)
+	assert.NoError(t, d.Cancel(handle.ID()))
+
+	select {
+	case result := <-response:
+		assert.Equal(t, "row-1", result.JobID)
+		assert.ErrorIs(t, result.Error, ErrJobCancelled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("cancelled job did not respond")
+	}
+}
@@ -2,15 +2,53 @@ package types
 
 import (
 	"context"
+	"errors"
+	"iter"
+	"sync"
 	"time"
 )
 
-// ResponseAggregator recolecta y procesa resultados de jobs
+// ErrAggregatorStopped indica que el agregador se detuvo antes de recibir los resultados esperados
+var ErrAggregatorStopped = errors.New("response aggregator stopped")
+
+// ResultSummary es el resumen acumulado de los resultados recibidos
+type ResultSummary struct {
+	Total     int           `json:"total"`
+	Succeeded int           `json:"succeeded"`
+	Failed    int           `json:"failed"`
+	Panicked  int           `json:"panicked"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// SuccessRate devuelve la fracción de resultados exitosos (0 si no hay resultados)
+func (s ResultSummary) SuccessRate() float64 {
+	if s.Total == 0 {
+		return 0
+	}
+	return float64(s.Succeeded) / float64(s.Total)
+}
+
+// ResponseAggregator recolecta y procesa resultados de jobs.
+//
+// Los workers entregan cada resultado con un envío bloqueante al canal de
+// GetResultsChannel, así que mientras el agregador corre un resultado nunca
+// se descarta: si va atrasado los workers esperan (backpressure). El canal
+// nunca se cierra; después de Stop nadie lo lee y un worker que reporta tarde
+// deja de esperar al vencer el timeout del job, por lo que Stop debe llamarse
+// cuando ya no quedan jobs por reportar.
 type ResponseAggregator struct {
 	results   chan JobResult
-	processed []JobResult
+	callbacks []func(JobResult)
 	ctx       context.Context
 	cancel    context.CancelFunc
+	done      chan struct{}
+	startOnce sync.Once
+
+	mu        sync.Mutex
+	processed []JobResult
+	summary   ResultSummary
+	changed   chan struct{}
+	stopped   bool
 }
 
 // NewResponseAggregator crea un nuevo agregador de respuestas
@@ -21,32 +59,109 @@ func NewResponseAggregator(bufferSize int) *ResponseAggregator {
 		processed: make([]JobResult, 0),
 		ctx:       ctx,
 		cancel:    cancel,
+		done:      make(chan struct{}),
+		changed:   make(chan struct{}),
 	}
 }
 
+// OnResult registra un callback que se invoca por cada resultado, en orden de
+// llegada y desde una única goroutine. Un callback lento frena a los workers.
+// Debe registrarse antes de Start.
+func (ra *ResponseAggregator) OnResult(callback func(JobResult)) {
+	ra.callbacks = append(ra.callbacks, callback)
+}
+
 // Start inicia el agregador
 func (ra *ResponseAggregator) Start() {
-	go func() {
-		for {
-			select {
-			case result := <-ra.results:
-				ra.processed = append(ra.processed, result)
-			case <-ra.ctx.Done():
-				return
-			}
+	ra.startOnce.Do(func() {
+		go ra.run()
+	})
+}
+
+// run consume el canal hasta que se detiene el agregador, procesando antes
+// lo que ya estaba en el buffer
+func (ra *ResponseAggregator) run() {
+	for {
+		select {
+		case result := <-ra.results:
+			ra.record(result)
+		case <-ra.ctx.Done():
+			ra.drain()
+			close(ra.done)
+			return
 		}
-	}()
+	}
 }
 
-// Stop detiene el agregador
+// drain registra los resultados que quedaron en el buffer
+func (ra *ResponseAggregator) drain() {
+	for {
+		select {
+		case result := <-ra.results:
+			ra.record(result)
+		default:
+			return
+		}
+	}
+}
+
+// record guarda el resultado, actualiza el resumen y despierta a los que esperan
+func (ra *ResponseAggregator) record(result JobResult) {
+	ra.mu.Lock()
+	ra.processed = append(ra.processed, result)
+	ra.summary.Total++
+	ra.summary.Duration += result.Duration
+	if result.Success {
+		ra.summary.Succeeded++
+	} else {
+		ra.summary.Failed++
+	}
+	if result.Panicked {
+		ra.summary.Panicked++
+	}
+	ra.broadcast()
+	ra.mu.Unlock()
+
+	for _, callback := range ra.callbacks {
+		callback(result)
+	}
+}
+
+// broadcast despierta a todos los que esperan un cambio (requiere ra.mu)
+func (ra *ResponseAggregator) broadcast() {
+	close(ra.changed)
+	ra.changed = make(chan struct{})
+}
+
+// Stop detiene el agregador luego de procesar los resultados que ya estaban
+// en el buffer. No cierra el canal de resultados: los que lleguen después no
+// se registran, y el worker que los envía deja de esperar al vencer el
+// timeout del job.
 func (ra *ResponseAggregator) Stop() {
+	ra.Start()
 	ra.cancel()
-	close(ra.results)
+	<-ra.done
+
+	ra.mu.Lock()
+	if !ra.stopped {
+		ra.stopped = true
+		ra.broadcast()
+	}
+	ra.mu.Unlock()
 }
 
-// GetResults devuelve todos los resultados procesados
+// GetResults devuelve una copia de los resultados procesados
 func (ra *ResponseAggregator) GetResults() []JobResult {
-	return ra.processed
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	return append([]JobResult(nil), ra.processed...)
+}
+
+// Summary devuelve el resumen de los resultados procesados hasta el momento
+func (ra *ResponseAggregator) Summary() ResultSummary {
+	ra.mu.Lock()
+	defer ra.mu.Unlock()
+	return ra.summary
 }
 
 // GetResultsChannel devuelve el canal para enviar resultados
@@ -54,22 +169,70 @@ func (ra *ResponseAggregator) GetResultsChannel() chan<- JobResult {
 	return ra.results
 }
 
+// Wait bloquea hasta haber procesado expectedCount resultados y los devuelve.
+// Si ctx vence o el agregador se detiene antes, devuelve los recibidos hasta
+// ese momento junto con el error.
+func (ra *ResponseAggregator) Wait(ctx context.Context, expectedCount int) ([]JobResult, error) {
+	for {
+		ra.mu.Lock()
+		if len(ra.processed) >= expectedCount {
+			results := append([]JobResult(nil), ra.processed...)
+			ra.mu.Unlock()
+			return results, nil
+		}
+		if ra.stopped {
+			results := append([]JobResult(nil), ra.processed...)
+			ra.mu.Unlock()
+			return results, ErrAggregatorStopped
+		}
+		changed := ra.changed
+		ra.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ra.GetResults(), ctx.Err()
+		}
+	}
+}
+
 // WaitForResults espera hasta recibir el número esperado de resultados o timeout
 func (ra *ResponseAggregator) WaitForResults(expectedCount int, timeout time.Duration) []JobResult {
-	deadline := time.After(timeout)
-	ticker := time.NewTicker(100 * time.Millisecond)
-	defer ticker.Stop()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
 
-	for {
-		select {
-		case <-deadline:
-			return ra.processed
-		case <-ticker.C:
-			if len(ra.processed) >= expectedCount {
-				return ra.processed
+	results, _ := ra.Wait(ctx, expectedCount)
+	return results
+}
+
+// Stream recorre todos los resultados, los ya procesados y los que van
+// llegando, hasta que se corte la iteración, venza ctx o se detenga el agregador
+func (ra *ResponseAggregator) Stream(ctx context.Context) iter.Seq[JobResult] {
+	return func(yield func(JobResult) bool) {
+		next := 0
+		for {
+			ra.mu.Lock()
+			if next < len(ra.processed) {
+				result := ra.processed[next]
+				ra.mu.Unlock()
+				next++
+				if !yield(result) {
+					return
+				}
+				continue
+			}
+			if ra.stopped {
+				ra.mu.Unlock()
+				return
+			}
+			changed := ra.changed
+			ra.mu.Unlock()
+
+			select {
+			case <-changed:
+			case <-ctx.Done():
+				return
 			}
-		case <-ra.ctx.Done():
-			return ra.processed
 		}
 	}
-}
\ No newline at end of file
+}
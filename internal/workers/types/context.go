@@ -0,0 +1,67 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+type heartbeatKey struct{}
+
+// WithHeartbeat devuelve un contexto que transporta la función de heartbeat del job
+func WithHeartbeat(ctx context.Context, beat func()) context.Context {
+	return context.WithValue(ctx, heartbeatKey{}, beat)
+}
+
+// Heartbeat señala que el job sigue vivo. No hace nada si el contexto no
+// proviene de un worker.
+func Heartbeat(ctx context.Context) {
+	if beat, ok := ctx.Value(heartbeatKey{}).(func()); ok {
+		beat()
+	}
+}
+
+type progressKey struct{}
+
+// Progress es el avance informado por un job en ejecución
+type Progress struct {
+	Current   int64     `json:"current"`
+	Total     int64     `json:"total,omitempty"`
+	Message   string    `json:"message,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Percent devuelve el avance en porcentaje (0 si no se conoce el total)
+func (p Progress) Percent() float64 {
+	if p.Total <= 0 {
+		return 0
+	}
+	return float64(p.Current) * 100 / float64(p.Total)
+}
+
+// WithProgress devuelve un contexto que transporta la función que registra el avance del job
+func WithProgress(ctx context.Context, report func(Progress)) context.Context {
+	return context.WithValue(ctx, progressKey{}, report)
+}
+
+// ReportProgress informa el avance del job (current de total, 0 si se
+// desconoce) con un mensaje opcional. También cuenta como heartbeat. No hace
+// nada si el contexto no proviene de un worker.
+func ReportProgress(ctx context.Context, current, total int64, message string) {
+	if report, ok := ctx.Value(progressKey{}).(func(Progress)); ok {
+		report(Progress{Current: current, Total: total, Message: message, UpdatedAt: time.Now()})
+	}
+}
+
+type requestIDKey struct{}
+
+// WithRequestID devuelve un contexto con el ID de la request que origina el
+// trabajo. El dispatcher lo captura al encolar y lo restaura al ejecutar el job.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID devuelve el ID de la request guardado en el contexto ("" si no hay)
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
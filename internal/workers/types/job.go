@@ -14,20 +14,101 @@ type Job interface {
 
 // JobResult representa el resultado de la ejecución de un job
 type JobResult struct {
-	JobID     string
-	JobName   string
-	Success   bool
-	Error     error
+	JobID   string
+	JobName string
+	Success bool
+	Error   error
+	// Data es el valor producido por un OutputJob que terminó con éxito
 	Data      interface{}
+	Attempts  int
 	Duration  time.Duration
 	Timestamp time.Time
+	// Panicked indica que el job hizo panic; Stack contiene la traza capturada
+	Panicked bool
+	Stack    string
 }
 
 // JobWithResponse extiende Job para incluir canal de respuesta
 type JobWithResponse interface {
 	Job
-	// ResponseChannel devuelve el canal donde enviar el resultado
+	// ResponseChannel devuelve el canal donde enviar el resultado (nil = no enviarlo)
 	ResponseChannel() chan<- JobResult
 	// ID devuelve un identificador único para el job
 	ID() string
-}
\ No newline at end of file
+}
+
+// SerializableJob extiende Job para poder persistirlo en colas durables
+type SerializableJob interface {
+	Job
+	// JobType devuelve el nombre con el que se registró el decoder del job
+	JobType() string
+	// Marshal serializa los datos necesarios para reconstruir el job
+	Marshal() ([]byte, error)
+}
+
+// TimeoutJob es un Job que declara su propio tiempo máximo de ejecución,
+// incluyendo todos sus reintentos
+type TimeoutJob interface {
+	Job
+	Timeout() time.Duration
+}
+
+// HeartbeatJob es un Job de larga duración que señala actividad con
+// Heartbeat(ctx). Si pasa más de HeartbeatTimeout sin señales, el pool lo
+// reporta como trabado.
+type HeartbeatJob interface {
+	Job
+	HeartbeatTimeout() time.Duration
+}
+
+// ResourceJob es un Job que declara el recurso downstream que consume (por
+// ejemplo "price-service"), usado para aplicarle límites de tasa compartidos
+type ResourceJob interface {
+	Job
+	ResourceKey() string
+}
+
+// IdempotentJob es un Job que declara una clave de idempotencia (por ejemplo
+// el ID de la oferta). Mientras haya un job en vuelo con la misma clave, un
+// nuevo envío se une a él en lugar de encolarse otra vez.
+type IdempotentJob interface {
+	Job
+	IdempotencyKey() string
+}
+
+// OrderedJob es un Job que declara una clave de orden (por ejemplo el ID de la
+// oferta). Los jobs con la misma clave se ejecutan de a uno y en el orden en
+// que se enviaron; los de claves distintas siguen en paralelo.
+type OrderedJob interface {
+	Job
+	OrderingKey() string
+}
+
+// QueuedJob es un Job que declara en qué cola con nombre debe ejecutarse
+type QueuedJob interface {
+	Job
+	QueueName() string
+}
+
+// OutputJob es un Job que produce un valor. Si termina con éxito, el worker
+// lo copia en JobResult.Data.
+type OutputJob interface {
+	Job
+	Output() interface{}
+}
+
+// JobOutput guarda el valor que produce un job. Embebido en un job usado por
+// puntero le agrega Output y SetOutput, con lo que implementa OutputJob.
+type JobOutput[T any] struct {
+	value T
+}
+
+// SetOutput guarda el valor producido; se llama desde Execute
+func (o *JobOutput[T]) SetOutput(value T) {
+	o.value = value
+}
+
+// Output devuelve el valor producido
+func (o *JobOutput[T]) Output() interface{} {
+	return o.value
+}
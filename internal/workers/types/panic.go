@@ -0,0 +1,14 @@
+package types
+
+import "fmt"
+
+// PanicError es el error con el que el worker reporta un job que hizo panic
+type PanicError struct {
+	Value interface{}
+	Stack string
+}
+
+// Error implementa la interfaz error
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("job panicked: %v", e.Value)
+}
@@ -0,0 +1,161 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy define cómo crece la espera entre reintentos
+type BackoffStrategy int
+
+const (
+	// BackoffExponential multiplica la espera por Multiplier en cada intento
+	BackoffExponential BackoffStrategy = iota
+	// BackoffLinear suma InitialInterval en cada intento
+	BackoffLinear
+	// BackoffConstant espera siempre InitialInterval
+	BackoffConstant
+)
+
+// RetryPolicy describe cuántas veces y con qué espera se reintenta un job
+type RetryPolicy struct {
+	// MaxAttempts es el total de ejecuciones permitidas, incluida la primera
+	MaxAttempts int
+	// Backoff es la estrategia de crecimiento de la espera
+	Backoff BackoffStrategy
+	// InitialInterval es la espera antes del primer reintento
+	InitialInterval time.Duration
+	// MaxInterval acota la espera entre reintentos (0 = sin límite)
+	MaxInterval time.Duration
+	// Multiplier es el factor de crecimiento para BackoffExponential
+	Multiplier float64
+	// Jitter es la variación aleatoria relativa aplicada a cada espera (0 a 1)
+	Jitter float64
+	// MaxElapsedTime acota el tiempo total entre el primer intento y el último (0 = sin límite)
+	MaxElapsedTime time.Duration
+	// Retryable clasifica qué errores se reintentan (nil = IsRetryable)
+	Retryable func(err error) bool
+}
+
+// RetryableJob es un Job que declara su propia política de reintentos
+type RetryableJob interface {
+	Job
+	RetryPolicy() RetryPolicy
+}
+
+// NoRetry es la política por defecto: una sola ejecución
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// ExponentialRetry crea una política con backoff exponencial y jitter del 20%
+func ExponentialRetry(maxAttempts int, initial time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		Backoff:         BackoffExponential,
+		InitialInterval: initial,
+		Multiplier:      2,
+		Jitter:          0.2,
+	}
+}
+
+// LinearRetry crea una política con backoff lineal
+func LinearRetry(maxAttempts int, step time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		Backoff:         BackoffLinear,
+		InitialInterval: step,
+	}
+}
+
+// ConstantRetry crea una política con espera fija entre intentos
+func ConstantRetry(maxAttempts int, interval time.Duration) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:     maxAttempts,
+		Backoff:         BackoffConstant,
+		InitialInterval: interval,
+	}
+}
+
+// Delay calcula la espera antes del reintento número attempt (1 = primer reintento)
+func (p RetryPolicy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	var delay float64
+	base := float64(p.InitialInterval)
+
+	switch p.Backoff {
+	case BackoffLinear:
+		delay = base * float64(attempt)
+	case BackoffConstant:
+		delay = base
+	default:
+		multiplier := p.Multiplier
+		if multiplier < 1 {
+			multiplier = 2
+		}
+		delay = base * math.Pow(multiplier, float64(attempt-1))
+	}
+
+	if p.MaxInterval > 0 && delay > float64(p.MaxInterval) {
+		delay = float64(p.MaxInterval)
+	}
+
+	if p.Jitter > 0 {
+		jitter := math.Min(p.Jitter, 1)
+		delay += delay * jitter * (rand.Float64()*2 - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// ShouldRetry decide si corresponde otro intento luego de attempts ejecuciones
+// fallidas y elapsed tiempo transcurrido, considerando la próxima espera
+func (p RetryPolicy) ShouldRetry(err error, attempts int, elapsed, nextDelay time.Duration) bool {
+	if err == nil || attempts >= p.MaxAttempts {
+		return false
+	}
+
+	if p.MaxElapsedTime > 0 && elapsed+nextDelay > p.MaxElapsedTime {
+		return false
+	}
+
+	retryable := p.Retryable
+	if retryable == nil {
+		retryable = IsRetryable
+	}
+	return retryable(err)
+}
+
+// permanentError marca un error que no debe reintentarse
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent envuelve un error para que la política no lo reintente
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsRetryable es el clasificador por defecto: reintenta todo salvo errores
+// permanentes, panics y cancelaciones del contexto
+func IsRetryable(err error) bool {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return false
+	}
+	var panicked *PanicError
+	if errors.As(err, &panicked) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,103 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryPolicyDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		want   []time.Duration
+	}{
+		{"exponential", RetryPolicy{Backoff: BackoffExponential, InitialInterval: time.Second, Multiplier: 2},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second}},
+		{"exponential defaults the multiplier to 2", RetryPolicy{Backoff: BackoffExponential, InitialInterval: time.Second},
+			[]time.Duration{time.Second, 2 * time.Second, 4 * time.Second}},
+		{"exponential capped", RetryPolicy{Backoff: BackoffExponential, InitialInterval: time.Second, Multiplier: 3, MaxInterval: 5 * time.Second},
+			[]time.Duration{time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second}},
+		{"linear", LinearRetry(5, time.Second),
+			[]time.Duration{time.Second, 2 * time.Second, 3 * time.Second}},
+		{"linear capped", RetryPolicy{Backoff: BackoffLinear, InitialInterval: time.Second, MaxInterval: 2 * time.Second},
+			[]time.Duration{time.Second, 2 * time.Second, 2 * time.Second}},
+		{"constant", ConstantRetry(5, time.Second),
+			[]time.Duration{time.Second, time.Second, time.Second}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([]time.Duration, 0, len(tt.want))
+			for attempt := 1; attempt <= len(tt.want); attempt++ {
+				got = append(got, tt.policy.Delay(attempt))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetryPolicyDelayJitter(t *testing.T) {
+	tests := []struct {
+		name     string
+		jitter   float64
+		min, max time.Duration
+	}{
+		{"20%", 0.2, 800 * time.Millisecond, 1200 * time.Millisecond},
+		{"above 1 is clamped", 5, 0, 2 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := RetryPolicy{Backoff: BackoffConstant, InitialInterval: time.Second, Jitter: tt.jitter}
+			varied := false
+			for range 200 {
+				delay := policy.Delay(1)
+				assert.GreaterOrEqual(t, delay, tt.min)
+				assert.LessOrEqual(t, delay, tt.max)
+				varied = varied || delay != time.Second
+			}
+			assert.True(t, varied, "jitter never changed the delay")
+		})
+	}
+}
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	transient := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		err      error
+		attempts int
+		elapsed  time.Duration
+		want     bool
+	}{
+		{"transient error", ConstantRetry(3, time.Second), transient, 1, 0, true},
+		{"attempts exhausted", ConstantRetry(3, time.Second), transient, 3, 0, false},
+		{"no error", ConstantRetry(3, time.Second), nil, 1, 0, false},
+		{"no retry policy", NoRetry, transient, 1, 0, false},
+		{"permanent error", ConstantRetry(3, time.Second), Permanent(transient), 1, 0, false},
+		{"wrapped permanent error", ConstantRetry(3, time.Second), fmt.Errorf("call: %w", Permanent(transient)), 1, 0, false},
+		{"panic", ConstantRetry(3, time.Second), &PanicError{Value: "boom"}, 1, 0, false},
+		{"cancelled", ConstantRetry(3, time.Second), context.Canceled, 1, 0, false},
+		{"deadline exceeded", ConstantRetry(3, time.Second), context.DeadlineExceeded, 1, 0, false},
+		{"within max elapsed", RetryPolicy{MaxAttempts: 3, InitialInterval: time.Second, Backoff: BackoffConstant, MaxElapsedTime: 10 * time.Second},
+			transient, 1, 8 * time.Second, true},
+		{"next delay passes max elapsed", RetryPolicy{MaxAttempts: 3, InitialInterval: time.Second, Backoff: BackoffConstant, MaxElapsedTime: 10 * time.Second},
+			transient, 1, 9500 * time.Millisecond, false},
+		{"custom classifier", RetryPolicy{MaxAttempts: 3, Retryable: func(err error) bool { return !errors.Is(err, transient) }},
+			transient, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay := tt.policy.Delay(tt.attempts)
+			assert.Equal(t, tt.want, tt.policy.ShouldRetry(tt.err, tt.attempts, tt.elapsed, delay))
+		})
+	}
+}
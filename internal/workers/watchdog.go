@@ -0,0 +1,142 @@
+package worker
+
+import (
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// watchdogInterval es cada cuánto el pool revisa los heartbeats de los jobs en ejecución
+const watchdogInterval = time.Second
+
+// runningJob es el estado de un job mientras un worker lo ejecuta
+type runningJob struct {
+	item             *QueueItem
+	workerID         int
+	startedAt        time.Time
+	heartbeatTimeout time.Duration
+	lastBeat         atomic.Int64
+	stuck            atomic.Bool
+}
+
+// beat registra un heartbeat del job
+func (r *runningJob) beat() {
+	r.lastBeat.Store(time.Now().UnixNano())
+	if r.stuck.Swap(false) {
+		log.Printf("Job %s (%s) is alive again", r.item.Job.Name(), r.item.ID)
+	}
+}
+
+// StuckJob describe un job en ejecución que dejó de enviar heartbeats
+type StuckJob struct {
+	ID            string        `json:"id"`
+	JobName       string        `json:"job_name"`
+	WorkerID      int           `json:"worker_id"`
+	StartedAt     time.Time     `json:"started_at"`
+	LastHeartbeat time.Time     `json:"last_heartbeat"`
+	Silence       time.Duration `json:"silence"`
+}
+
+// startRunning registra que un worker empezó a ejecutar el item
+func (p *Pool) startRunning(item *QueueItem, workerID int, heartbeatTimeout time.Duration) *runningJob {
+	run := &runningJob{
+		item:             item,
+		workerID:         workerID,
+		startedAt:        time.Now(),
+		heartbeatTimeout: heartbeatTimeout,
+	}
+	run.lastBeat.Store(run.startedAt.UnixNano())
+
+	p.runMu.Lock()
+	p.running[item.ID] = run
+	p.runMu.Unlock()
+
+	return run
+}
+
+// stopRunning quita el item de los jobs en ejecución
+func (p *Pool) stopRunning(id string) {
+	p.runMu.Lock()
+	delete(p.running, id)
+	p.runMu.Unlock()
+}
+
+// watchdog revisa periódicamente los jobs en ejecución y reporta los que
+// superaron su HeartbeatTimeout sin dar señales (usado con waitgroup.Go)
+func (p *Pool) watchdog() {
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			for _, stuck := range p.detectStuck(time.Now()) {
+				log.Printf("Job %s (%s) on worker %d looks stuck: no heartbeat for %v",
+					stuck.JobName, stuck.ID, stuck.WorkerID, stuck.Silence.Round(time.Second))
+			}
+		case <-p.ctx.Done():
+			return
+		}
+	}
+}
+
+// detectStuck marca como trabados los jobs sin heartbeat reciente y devuelve
+// los recién detectados
+func (p *Pool) detectStuck(now time.Time) []StuckJob {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	detected := make([]StuckJob, 0)
+	for _, run := range p.running {
+		if run.heartbeatTimeout <= 0 {
+			continue
+		}
+
+		last := time.Unix(0, run.lastBeat.Load())
+		if now.Sub(last) <= run.heartbeatTimeout || run.stuck.Swap(true) {
+			continue
+		}
+
+		p.stuckDetections.Add(1)
+		detected = append(detected, run.snapshot(now))
+	}
+	return detected
+}
+
+// StuckJobs devuelve los jobs actualmente marcados como trabados
+func (p *Pool) StuckJobs() []StuckJob {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+
+	now := time.Now()
+	stuck := make([]StuckJob, 0)
+	for _, run := range p.running {
+		if run.stuck.Load() {
+			stuck = append(stuck, run.snapshot(now))
+		}
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].StartedAt.Before(stuck[j].StartedAt)
+	})
+	return stuck
+}
+
+// StuckDetections devuelve cuántas veces se detectó un job trabado
+func (p *Pool) StuckDetections() int64 {
+	return p.stuckDetections.Load()
+}
+
+// snapshot describe el job como StuckJob
+func (r *runningJob) snapshot(now time.Time) StuckJob {
+	last := time.Unix(0, r.lastBeat.Load())
+	return StuckJob{
+		ID:            r.item.ID,
+		JobName:       r.item.Job.Name(),
+		WorkerID:      r.workerID,
+		StartedAt:     r.startedAt,
+		LastHeartbeat: last,
+		Silence:       now.Sub(last),
+	}
+}
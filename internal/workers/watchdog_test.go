@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// heartbeatJob es un testJob que declara un timeout de heartbeat
+type heartbeatJob struct {
+	*testJob
+	heartbeatTimeout time.Duration
+}
+
+func (j *heartbeatJob) HeartbeatTimeout() time.Duration { return j.heartbeatTimeout }
+
+func TestWatchdogFlagsSilentJobs(t *testing.T) {
+	d := NewDispatcher(context.Background(), 2, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	silent, err := d.Submit(&heartbeatJob{testJob: newTestJob("silent", func(ctx context.Context) error {
+		<-release
+		return nil
+	}), heartbeatTimeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	beating, err := d.Submit(&heartbeatJob{testJob: newTestJob("beating", func(ctx context.Context) error {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				types.Heartbeat(ctx)
+			case <-release:
+				return nil
+			}
+		}
+	}), heartbeatTimeout: 50 * time.Millisecond})
+	assert.NoError(t, err)
+
+	waitStatus(t, func() JobStatus { return silent.Status().Status }, JobRunning)
+	waitStatus(t, func() JobStatus { return beating.Status().Status }, JobRunning)
+	time.Sleep(100 * time.Millisecond)
+
+	// El watchdog del pool pudo adelantarse: lo detectado acá nunca es el job
+	// que da señales, y uno ya marcado no se vuelve a contar
+	for _, stuck := range d.workerPool.detectStuck(time.Now()) {
+		assert.Equal(t, silent.ID(), stuck.ID)
+		assert.GreaterOrEqual(t, stuck.Silence, 50*time.Millisecond)
+	}
+	assert.Empty(t, d.workerPool.detectStuck(time.Now()))
+
+	stats := d.Stats()
+	assert.EqualValues(t, 1, stats.StuckDetections)
+	if assert.Len(t, stats.StuckJobs, 1) {
+		assert.Equal(t, "silent", stats.StuckJobs[0].JobName)
+	}
+}
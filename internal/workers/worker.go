@@ -2,7 +2,10 @@ package worker
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
+	"runtime/debug"
 	"sync/atomic"
 	"time"
 
@@ -12,27 +15,49 @@ import (
 // Worker representa un trabajador individual que procesa jobs
 type Worker struct {
 	ID         int
-	jobChannel chan Job
-	workerPool chan chan Job
+	jobChannel chan *QueueItem
+	workerPool chan chan *QueueItem
+	pool       *Pool
 	ctx        context.Context
-	metrics    *WorkerMetrics
+	startedAt  time.Time
+	metrics    workerCounters
 }
 
-// WorkerMetrics almacena métricas del worker
+// workerCounters son los contadores del worker, actualizados atómicamente
+type workerCounters struct {
+	jobsProcessed atomic.Int64
+	errors        atomic.Int64
+	panics        atomic.Int64
+	lastJobTime   atomic.Int64
+	// busyTime acumula el tiempo ejecutando jobs y busySince marca el inicio
+	// del job actual (0 si está ocioso)
+	busyTime  atomic.Int64
+	busySince atomic.Int64
+}
+
+// WorkerMetrics es una copia de las métricas del worker
 type WorkerMetrics struct {
-	JobsProcessed int64
-	Errors        int64
-	LastJobTime   time.Duration
+	WorkerID      int           `json:"worker_id"`
+	Queue         string        `json:"queue"`
+	JobsProcessed int64         `json:"jobs_processed"`
+	Errors        int64         `json:"errors"`
+	Panics        int64         `json:"panics"`
+	LastJobTime   time.Duration `json:"last_job_time"`
+	BusyTime      time.Duration `json:"busy_time"`
+	Uptime        time.Duration `json:"uptime"`
+	// Utilization es la fracción del tiempo de vida que pasó ejecutando jobs
+	Utilization float64 `json:"utilization"`
 }
 
-// NewWorker crea un nuevo worker
-func NewWorker(id int, workerPool chan chan Job, ctx context.Context) *Worker {
+// NewWorker crea un nuevo worker asociado al pool
+func NewWorker(id int, pool *Pool) *Worker {
 	return &Worker{
 		ID:         id,
-		jobChannel: make(chan Job),
-		workerPool: workerPool,
-		ctx:        ctx,
-		metrics:    &WorkerMetrics{},
+		jobChannel: make(chan *QueueItem),
+		workerPool: pool.workerPool,
+		pool:       pool,
+		ctx:        pool.ctx,
+		startedAt:  time.Now(),
 	}
 }
 
@@ -52,8 +77,14 @@ func (w *Worker) run() {
 
 		// Esperar a recibir un trabajo o señal de cierre
 		select {
-		case job := <-w.jobChannel:
-			w.processJob(job)
+		case item := <-w.jobChannel:
+			if item == nil {
+				// El pool retiró a este worker
+				log.Printf("Worker %d retired", w.ID)
+				return
+			}
+			result := w.processJob(item)
+			w.pool.finish(item, result)
 		case <-w.ctx.Done():
 			log.Printf("Worker %d shutting down", w.ID)
 			return
@@ -61,53 +92,212 @@ func (w *Worker) run() {
 	}
 }
 
-// processJob procesa un trabajo individual
-func (w *Worker) processJob(job Job) {
-	log.Printf("Worker %d processing job: %s", w.ID, job.Name())
-
+// processJob procesa un trabajo individual y devuelve su resultado
+func (w *Worker) processJob(item *QueueItem) types.JobResult {
+	job := item.Job
 	startTime := time.Now()
 
-	// Crear un contexto derivado con timeout para el job
-	jobCtx, cancel := context.WithTimeout(w.ctx, 5*time.Minute)
+	// Crear un contexto derivado con el timeout del job (o el del pool)
+	timeout := w.pool.timeout(job)
+	jobCtx, cancel := context.WithTimeout(w.ctx, timeout)
 	defer cancel()
 
-	// Ejecutar el trabajo
-	err := job.Execute(jobCtx)
+	// Restaurar los valores de contexto de quien encoló el job
+	jobCtx = w.pool.restoreContext(jobCtx, item)
 
-	duration := time.Since(startTime)
-	w.metrics.LastJobTime = duration
-
-	// Si el job implementa JobWithResponse, enviar el resultado
-	if jobWithResponse, ok := job.(types.JobWithResponse); ok {
+	// Registrar la cancelación del job para que Pool.Cancel pueda interrumpirlo
+	jobCtx, cancelJob := context.WithCancelCause(jobCtx)
+	defer cancelJob(nil)
+	if !w.pool.jobs.start(item, w.ID, cancelJob) {
+		log.Printf("Worker %d skipping cancelled job %s", w.ID, jobLabel(item))
+		w.pool.metrics.cancelled(item)
 		result := types.JobResult{
-			JobID:     jobWithResponse.ID(),
+			JobID:     item.ID,
 			JobName:   job.Name(),
-			Success:   err == nil,
-			Error:     err,
-			Duration:  duration,
+			Error:     ErrJobCancelled,
 			Timestamp: time.Now(),
 		}
+		if jobWithResponse, ok := job.(types.JobWithResponse); ok && jobWithResponse.ResponseChannel() != nil {
+			w.pool.respond(jobWithResponse, result, timeout)
+		}
+		return result
+	}
 
-		// Intentar enviar resultado al canal
-		select {
-		case jobWithResponse.ResponseChannel() <- result:
-			// Resultado enviado exitosamente
-		default:
-			// Canal lleno o cerrado, log de advertencia
-			log.Printf("Worker %d: failed to send result for job %s", w.ID, job.Name())
+	// Registrar el job en ejecución para que el watchdog vigile sus heartbeats
+	var heartbeatTimeout time.Duration
+	if heartbeatJob, ok := job.(types.HeartbeatJob); ok {
+		heartbeatTimeout = heartbeatJob.HeartbeatTimeout()
+	}
+	w.pool.metrics.started(item, startTime)
+	w.metrics.busySince.Store(startTime.UnixNano())
+	run := w.pool.startRunning(item, w.ID, heartbeatTimeout)
+	defer w.pool.stopRunning(item.ID)
+	jobCtx = types.WithHeartbeat(jobCtx, run.beat)
+	jobCtx = types.WithProgress(jobCtx, func(progress types.Progress) {
+		run.beat()
+		w.pool.jobs.progress(item.ID, progress)
+	})
+
+	// Ejecutar el trabajo aplicando su política de reintentos
+	attempts, err := w.execute(jobCtx, job)
+	if err != nil && context.Cause(jobCtx) == ErrJobCancelled && !errors.Is(err, ErrJobCancelled) {
+		err = fmt.Errorf("%w: %w", ErrJobCancelled, err)
+	}
+
+	duration := time.Since(startTime)
+	w.metrics.lastJobTime.Store(int64(duration))
+	w.metrics.busyTime.Add(int64(duration))
+	w.metrics.busySince.Store(0)
+
+	result := types.JobResult{
+		JobID:     item.ID,
+		JobName:   job.Name(),
+		Success:   err == nil,
+		Error:     err,
+		Attempts:  attempts,
+		Duration:  duration,
+		Timestamp: time.Now(),
+	}
+
+	var panicErr *types.PanicError
+	if errors.As(err, &panicErr) {
+		result.Panicked = true
+		result.Stack = panicErr.Stack
+	}
+	if outputJob, ok := job.(types.OutputJob); ok && err == nil {
+		result.Data = outputJob.Output()
+	}
+
+	// Si el job implementa JobWithResponse con un canal, enviar el resultado
+	if jobWithResponse, ok := job.(types.JobWithResponse); ok && jobWithResponse.ResponseChannel() != nil {
+		if !w.pool.respond(jobWithResponse, result, timeout) {
+			log.Printf("Worker %d: result for job %s not delivered, nobody is reading its response channel", w.ID, job.Name())
 		}
 	}
 
+	w.pool.metrics.finished(item, result)
 	if err != nil {
-		log.Printf("Worker %d error processing job %s: %v", w.ID, job.Name(), err)
-		atomic.AddInt64(&w.metrics.Errors, 1)
+		log.Printf("Worker %d error processing job %s: %v", w.ID, jobLabel(item), err)
+		w.metrics.errors.Add(1)
 	} else {
-		log.Printf("Worker %d completed job %s in %v", w.ID, job.Name(), duration)
-		atomic.AddInt64(&w.metrics.JobsProcessed, 1)
+		w.metrics.jobsProcessed.Add(1)
+	}
+
+	return result
+}
+
+// respond envía el resultado al canal de respuesta del job. Lo envía con
+// bloqueo para no perderlo: si el receptor va atrasado espera, pero como mucho
+// wait, por si el receptor dejó de leer (por ejemplo un agregador detenido o
+// que ya no existe para un dead letter reencolado), o hasta que se detenga el
+// pool. Devuelve false si no se pudo entregar.
+func (p *Pool) respond(responder types.JobWithResponse, result types.JobResult, wait time.Duration) bool {
+	response := result
+	response.JobID = responder.ID()
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case responder.ResponseChannel() <- response:
+		return true
+	case <-timer.C:
+		return false
+	case <-p.ctx.Done():
+		return false
+	}
+}
+
+// execute ejecuta el job reintentando según su RetryPolicy (o la del pool si
+// no declara una o declara MaxAttempts <= 0) y devuelve cuántos intentos hizo
+func (w *Worker) execute(ctx context.Context, job Job) (int, error) {
+	policy := w.pool.retryPolicy
+	if retryable, ok := job.(types.RetryableJob); ok {
+		if declared := retryable.RetryPolicy(); declared.MaxAttempts > 0 {
+			policy = declared
+		}
+	}
+
+	handler := w.pool.handler(job)
+	start := time.Now()
+	attempts := 0
+
+	for {
+		// El token del primer intento se tomó antes de entregar el job; los
+		// reintentos esperan el suyo dentro del timeout del job, como su backoff
+		if attempts > 0 {
+			if err := w.pool.rateLimits.Wait(ctx, job); err != nil {
+				return attempts, err
+			}
+		}
+		attempts++
+
+		err := w.safeExecute(ctx, job, handler)
+		if err == nil {
+			return attempts, nil
+		}
+
+		delay := policy.Delay(attempts)
+		if !policy.ShouldRetry(err, attempts, time.Since(start), delay) {
+			if attempts > 1 {
+				err = fmt.Errorf("job %s failed after %d attempts: %w", job.Name(), attempts, err)
+			}
+			return attempts, err
+		}
+
+		log.Printf("Worker %d: job %s failed (attempt %d/%d): %v, retrying in %v",
+			w.ID, job.Name(), attempts, policy.MaxAttempts, err, delay)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return attempts, ctx.Err()
+		}
 	}
 }
 
-// Metrics devuelve las métricas del worker
+// safeExecute ejecuta el job a través de su cadena de middlewares recuperando
+// un posible panic, que se devuelve como *types.PanicError para que siga el
+// mismo camino que un error
+func (w *Worker) safeExecute(ctx context.Context, job Job, handler Handler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			w.metrics.panics.Add(1)
+			err = &types.PanicError{Value: recovered, Stack: string(debug.Stack())}
+			log.Printf("Worker %d: job %s panicked: %v", w.ID, job.Name(), recovered)
+		}
+	}()
+
+	return handler(ctx, job)
+}
+
+// Metrics devuelve una copia de las métricas del worker
 func (w *Worker) Metrics() WorkerMetrics {
-	return *w.metrics
+	return w.metricsAt(time.Now())
+}
+
+// metricsAt calcula las métricas del worker al momento indicado, incluyendo
+// el tiempo del job en curso
+func (w *Worker) metricsAt(now time.Time) WorkerMetrics {
+	busy := time.Duration(w.metrics.busyTime.Load())
+	if since := w.metrics.busySince.Load(); since > 0 {
+		busy += now.Sub(time.Unix(0, since))
+	}
+
+	metrics := WorkerMetrics{
+		WorkerID:      w.ID,
+		Queue:         w.pool.name,
+		JobsProcessed: w.metrics.jobsProcessed.Load(),
+		Errors:        w.metrics.errors.Load(),
+		Panics:        w.metrics.panics.Load(),
+		LastJobTime:   time.Duration(w.metrics.lastJobTime.Load()),
+		BusyTime:      busy,
+		Uptime:        now.Sub(w.startedAt),
+	}
+	if metrics.Uptime > 0 {
+		metrics.Utilization = min(float64(busy)/float64(metrics.Uptime), 1)
+	}
+	return metrics
 }
@@ -0,0 +1,236 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnreadResponseDoesNotWedgeWorker(t *testing.T) {
+	tests := []struct {
+		name     string
+		timeout  time.Duration
+		response func() chan<- types.JobResult
+	}{
+		{"nobody reading", 50 * time.Millisecond, func() chan<- types.JobResult {
+			return make(chan types.JobResult)
+		}},
+		{"stopped aggregator", 50 * time.Millisecond, func() chan<- types.JobResult {
+			aggregator := types.NewResponseAggregator(0)
+			aggregator.Start()
+			aggregator.Stop()
+			return aggregator.GetResultsChannel()
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			response := tt.response()
+			for i := range 3 {
+				job := &responseJob{testJob: newTestJob("job", nil), id: fmt.Sprintf("job-%d", i), response: response, timeout: tt.timeout}
+				_, err := d.Submit(job)
+				assert.NoError(t, err)
+			}
+
+			assert.Eventually(t, func() bool {
+				return d.workerPool.Metrics().Succeeded == 3
+			}, 2*time.Second, 10*time.Millisecond)
+		})
+	}
+}
+
+func TestPanickingJobKeepsWorkerAlive(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	handle, err := d.Submit(newTestJob("panics", func(context.Context) error {
+		panic("boom")
+	}))
+	assert.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	result, err := handle.Wait(ctx)
+	assert.NoError(t, err)
+
+	assert.False(t, result.Success)
+	assert.True(t, result.Panicked)
+	assert.NotEmpty(t, result.Stack)
+	var panicErr *types.PanicError
+	if assert.ErrorAs(t, result.Error, &panicErr) {
+		assert.Equal(t, "boom", panicErr.Value)
+	}
+
+	// El mismo worker sigue tomando jobs
+	handle, err = d.Submit(newTestJob("after-panic", nil))
+	assert.NoError(t, err)
+	result, err = handle.Wait(ctx)
+	assert.NoError(t, err)
+	assert.True(t, result.Success)
+
+	metrics := d.workerPool.Metrics()
+	assert.EqualValues(t, 1, metrics.Panicked)
+	assert.EqualValues(t, 1, metrics.Succeeded)
+	assert.Equal(t, 1, d.workerPool.Size())
+	if assert.Len(t, metrics.Workers, 1) {
+		assert.EqualValues(t, 1, metrics.Workers[0].Panics)
+	}
+}
+
+func TestRetryPolicyClassifiesPanics(t *testing.T) {
+	tests := []struct {
+		name      string
+		retryable func(error) bool
+		attempts  int
+		success   bool
+	}{
+		{"default policy does not retry a panic", nil, 1, false},
+		{"classifier retries a panic", func(err error) bool {
+			var panicErr *types.PanicError
+			return errors.As(err, &panicErr)
+		}, 2, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			policy := types.ConstantRetry(3, time.Millisecond)
+			policy.Retryable = tt.retryable
+			calls := 0
+			job := &retryJob{
+				testJob: newTestJob("panics-once", func(context.Context) error {
+					calls++
+					if calls == 1 {
+						panic("boom")
+					}
+					return nil
+				}),
+				policy: policy,
+			}
+
+			handle, err := d.Submit(job)
+			assert.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			result, err := handle.Wait(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.attempts, result.Attempts)
+		})
+	}
+}
+
+func TestWorkerRetriesFailedAttempts(t *testing.T) {
+	transient := errors.New("connection reset")
+
+	tests := []struct {
+		name     string
+		failures int
+		err      error
+		attempts int
+		success  bool
+	}{
+		{"recovers after transient errors", 2, transient, 3, true},
+		{"gives up after max attempts", 5, transient, 3, false},
+		{"permanent error is not retried", 5, types.Permanent(transient), 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			calls := 0
+			job := &retryJob{
+				testJob: newTestJob("flaky", func(context.Context) error {
+					calls++
+					if calls <= tt.failures {
+						return tt.err
+					}
+					return nil
+				}),
+				policy: types.ExponentialRetry(3, time.Millisecond),
+			}
+
+			handle, err := d.Submit(job)
+			assert.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			result, err := handle.Wait(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.success, result.Success)
+			assert.Equal(t, tt.attempts, result.Attempts)
+			assert.Equal(t, tt.attempts, calls)
+			if !tt.success {
+				assert.ErrorIs(t, result.Error, transient)
+			}
+		})
+	}
+}
+
+// timedJob es un testJob con su propio timeout
+type timedJob struct {
+	*testJob
+	timeout time.Duration
+}
+
+func (j *timedJob) Timeout() time.Duration { return j.timeout }
+
+func TestJobTimeoutOverridesPoolTimeout(t *testing.T) {
+	tests := []struct {
+		name        string
+		poolTimeout time.Duration
+		jobTimeout  time.Duration
+		success     bool
+	}{
+		{"longer than the pool timeout", 20 * time.Millisecond, time.Second, true},
+		{"shorter than the pool timeout", time.Minute, 20 * time.Millisecond, false},
+		{"zero falls back to the pool timeout", 20 * time.Millisecond, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 1, 10, WithPoolOptions(WithJobTimeout(tt.poolTimeout)))
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			job := &timedJob{testJob: newTestJob("timed", func(ctx context.Context) error {
+				select {
+				case <-time.After(100 * time.Millisecond):
+					return nil
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}), timeout: tt.jobTimeout}
+
+			handle, err := d.Submit(job)
+			assert.NoError(t, err)
+			ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+			defer cancel()
+			result, err := handle.Wait(ctx)
+			assert.NoError(t, err)
+
+			assert.Equal(t, tt.success, result.Success)
+			if !tt.success {
+				assert.ErrorIs(t, result.Error, context.DeadlineExceeded)
+			}
+		})
+	}
+}
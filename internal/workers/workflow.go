@@ -0,0 +1,394 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+)
+
+// WorkflowMode define qué hacer cuando falla un paso del workflow
+type WorkflowMode int
+
+const (
+	// FailFast cancela los pasos en ejecución y no lanza ninguno más
+	FailFast WorkflowMode = iota
+	// ContinueOnError omite solo los descendientes del paso fallido
+	ContinueOnError
+)
+
+// StepFunc es la función de un paso. Recibe las salidas de sus dependencias
+// indexadas por nombre de paso y devuelve su propia salida.
+type StepFunc func(ctx context.Context, inputs map[string]interface{}) (interface{}, error)
+
+// StepOption configura parámetros opcionales de un paso
+type StepOption func(*workflowStep)
+
+// WithStepPriority define la prioridad con la que se encola el paso
+func WithStepPriority(priority int) StepOption {
+	return func(s *workflowStep) {
+		s.priority = priority
+	}
+}
+
+// WithStepRetry define la política de reintentos del paso
+func WithStepRetry(policy types.RetryPolicy) StepOption {
+	return func(s *workflowStep) {
+		s.retry = policy
+	}
+}
+
+// JobStep adapta un Job existente como paso de workflow sin salida. Solo
+// ejecuta su Execute: para que el paso conserve el RetryPolicy, el Timeout y
+// el ResourceKey del job, agregarlo con AddJobStep.
+func JobStep(job Job) StepFunc {
+	return func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+		return nil, job.Execute(ctx)
+	}
+}
+
+// workflowStep es un nodo del DAG
+type workflowStep struct {
+	name      string
+	fn        StepFunc
+	dependsOn []string
+	priority  int
+	retry     types.RetryPolicy
+	// job es el Job adaptado con AddJobStep (nil para los pasos de AddStep)
+	job Job
+}
+
+// Workflow es un DAG de pasos que se ejecutan en el pool del dispatcher
+// respetando sus dependencias
+type Workflow struct {
+	name  string
+	mode  WorkflowMode
+	steps map[string]*workflowStep
+	order []string
+}
+
+// WorkflowResult es el resultado agregado del workflow junto con el de cada paso.
+// JobResult.Data contiene las salidas de los pasos exitosos indexadas por nombre.
+type WorkflowResult struct {
+	types.JobResult
+	Steps   map[string]types.JobResult
+	Skipped []string
+}
+
+// NewWorkflow crea un workflow vacío
+func NewWorkflow(name string, mode WorkflowMode) *Workflow {
+	return &Workflow{
+		name:  name,
+		mode:  mode,
+		steps: make(map[string]*workflowStep),
+	}
+}
+
+// AddStep agrega un paso que se ejecuta cuando todas sus dependencias terminaron con éxito
+func (w *Workflow) AddStep(name string, fn StepFunc, dependsOn []string, opts ...StepOption) error {
+	if _, exists := w.steps[name]; exists {
+		return fmt.Errorf("workflow %s: step %q already exists", w.name, name)
+	}
+
+	step := &workflowStep{
+		name:      name,
+		fn:        fn,
+		dependsOn: dependsOn,
+		priority:  1,
+	}
+	for _, opt := range opts {
+		opt(step)
+	}
+
+	w.steps[name] = step
+	w.order = append(w.order, name)
+	return nil
+}
+
+// AddJobStep agrega como paso un Job existente, sin salida. A diferencia de
+// AddStep con JobStep, el paso usa el RetryPolicy (salvo que se indique
+// WithStepRetry), el Timeout y el ResourceKey que declare el job.
+func (w *Workflow) AddJobStep(name string, job Job, dependsOn []string, opts ...StepOption) error {
+	opts = append([]StepOption{WithStepPriority(job.Priority()), func(s *workflowStep) {
+		s.job = job
+	}}, opts...)
+	return w.AddStep(name, JobStep(job), dependsOn, opts...)
+}
+
+// validate verifica que las dependencias existan y que no haya ciclos
+func (w *Workflow) validate() error {
+	indegree := make(map[string]int, len(w.steps))
+	for _, name := range w.order {
+		for _, dep := range w.steps[name].dependsOn {
+			if _, ok := w.steps[dep]; !ok {
+				return fmt.Errorf("workflow %s: step %q depends on unknown step %q", w.name, name, dep)
+			}
+		}
+		indegree[name] = len(w.steps[name].dependsOn)
+	}
+
+	ready := make([]string, 0)
+	for _, name := range w.order {
+		if indegree[name] == 0 {
+			ready = append(ready, name)
+		}
+	}
+
+	visited := 0
+	children := w.children()
+	for len(ready) > 0 {
+		name := ready[0]
+		ready = ready[1:]
+		visited++
+		for _, child := range children[name] {
+			indegree[child]--
+			if indegree[child] == 0 {
+				ready = append(ready, child)
+			}
+		}
+	}
+
+	if visited != len(w.steps) {
+		return fmt.Errorf("workflow %s: dependency cycle detected", w.name)
+	}
+	return nil
+}
+
+// children devuelve, para cada paso, los pasos que dependen de él
+func (w *Workflow) children() map[string][]string {
+	children := make(map[string][]string, len(w.steps))
+	for _, name := range w.order {
+		for _, dep := range w.steps[name].dependsOn {
+			children[dep] = append(children[dep], name)
+		}
+	}
+	return children
+}
+
+// stepJob es el Job que se encola en el pool para ejecutar un paso. El
+// workflow espera su resultado con el JobHandle, así un paso que termina en la
+// cola de mensajes muertos y se reencola después no queda esperando a nadie.
+type stepJob struct {
+	workflow string
+	step     *workflowStep
+	inputs   map[string]interface{}
+	ctx      context.Context
+	output   interface{}
+}
+
+// Execute implementa la interfaz Job, cancelándose también si se cancela el workflow
+func (j *stepJob) Execute(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(j.ctx, cancel)
+	defer stop()
+
+	output, err := j.step.fn(ctx, j.inputs)
+	j.output = output
+	return err
+}
+
+// Name implementa la interfaz Job
+func (j *stepJob) Name() string {
+	return fmt.Sprintf("workflow-%s-%s", j.workflow, j.step.name)
+}
+
+// Priority implementa la interfaz Job
+func (j *stepJob) Priority() int {
+	return j.step.priority
+}
+
+// Output implementa la interfaz OutputJob
+func (j *stepJob) Output() interface{} {
+	return j.output
+}
+
+// RetryPolicy implementa la interfaz RetryableJob. Sin WithStepRetry usa la
+// del job de AddJobStep, si declara una.
+func (j *stepJob) RetryPolicy() types.RetryPolicy {
+	if retryable, ok := j.step.job.(types.RetryableJob); ok && j.step.retry.MaxAttempts <= 0 {
+		return retryable.RetryPolicy()
+	}
+	return j.step.retry
+}
+
+// Timeout implementa la interfaz TimeoutJob con el del job de AddJobStep
+// (cero usa el del pool)
+func (j *stepJob) Timeout() time.Duration {
+	if timeoutJob, ok := j.step.job.(types.TimeoutJob); ok {
+		return timeoutJob.Timeout()
+	}
+	return 0
+}
+
+// ResourceKey implementa la interfaz ResourceJob con el del job de AddJobStep
+func (j *stepJob) ResourceKey() string {
+	if resourceJob, ok := j.step.job.(types.ResourceJob); ok {
+		return resourceJob.ResourceKey()
+	}
+	return ""
+}
+
+// RunWorkflow ejecuta el workflow en el pool y bloquea hasta que termina.
+// Los pasos se encolan con SubmitWithContext(ctx), así reciben el ID de request
+// y los valores propagados de ctx. Devuelve error si el workflow es inválido o
+// si algún paso falló.
+func (d *Dispatcher) RunWorkflow(ctx context.Context, wf *Workflow) (*WorkflowResult, error) {
+	if err := wf.validate(); err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	wfCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	children := wf.children()
+	pending := make(map[string]int, len(wf.steps))
+	for name, step := range wf.steps {
+		pending[name] = len(step.dependsOn)
+	}
+
+	// finished recibe el nombre de cada paso cuyo job terminó; tiene lugar para
+	// todos los pasos, así ninguna espera se bloquea al avisar
+	finished := make(chan string, len(wf.steps))
+	handles := make(map[string]*JobHandle, len(wf.steps))
+	outputs := make(map[string]interface{}, len(wf.steps))
+
+	report := &WorkflowResult{Steps: make(map[string]types.JobResult, len(wf.steps))}
+	skipped := make(map[string]bool)
+	var errs []error
+	inFlight := 0
+	stopped := false
+
+	submit := func(name string) {
+		step := wf.steps[name]
+		inputs := make(map[string]interface{}, len(step.dependsOn))
+		for _, dep := range step.dependsOn {
+			inputs[dep] = outputs[dep]
+		}
+
+		// Con la cola llena la espera termina si vence ctx, y el paso recibe
+		// los valores de contexto de quien ejecuta el workflow
+		job := &stepJob{workflow: wf.name, step: step, inputs: inputs, ctx: wfCtx}
+		handle, err := d.SubmitWithContext(wfCtx, job)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("step %s: %w", name, err))
+			report.Steps[name] = types.JobResult{JobID: name, JobName: job.Name(), Error: err, Timestamp: time.Now()}
+			if !stopped {
+				log.Printf("Workflow %s: failed to submit step %s, cancelling remaining steps", wf.name, name)
+				stopped = true
+				cancel()
+			}
+			return
+		}
+		handles[name] = handle
+		inFlight++
+
+		go func() {
+			select {
+			case <-handle.Done():
+				finished <- name
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	var skip func(name string)
+	skip = func(name string) {
+		if skipped[name] {
+			return
+		}
+		skipped[name] = true
+		report.Skipped = append(report.Skipped, name)
+		for _, child := range children[name] {
+			skip(child)
+		}
+	}
+
+	for _, name := range wf.order {
+		if pending[name] == 0 && !stopped {
+			submit(name)
+		}
+	}
+
+	for inFlight > 0 && ctx.Err() == nil {
+		var name string
+		select {
+		case name = <-finished:
+		case <-ctx.Done():
+			continue
+		}
+		inFlight--
+
+		result, _ := handles[name].Wait(context.Background())
+		result.JobID = name
+		delete(handles, name)
+		report.Steps[name] = result
+
+		if !result.Success {
+			errs = append(errs, fmt.Errorf("step %s: %w", name, result.Error))
+			if wf.mode == FailFast && !stopped {
+				log.Printf("Workflow %s: step %s failed, cancelling remaining steps", wf.name, name)
+				stopped = true
+				cancel()
+			}
+			for _, child := range children[name] {
+				skip(child)
+			}
+			continue
+		}
+
+		outputs[name] = result.Data
+		for _, child := range children[name] {
+			pending[child]--
+			if pending[child] == 0 && !skipped[child] && !stopped {
+				submit(child)
+			}
+		}
+	}
+
+	if ctx.Err() != nil {
+		// Los pasos que siguen en la cola (por ejemplo con el pool pausado o
+		// detenido) se cancelan y los que están en ejecución ven cancelado su
+		// contexto; no se espera a que terminen
+		stopped = true
+		cancel()
+		for name, handle := range handles {
+			handle.Cancel()
+			report.Steps[name] = types.JobResult{JobID: name, JobName: handle.Status().JobName, Error: ctx.Err(), Timestamp: time.Now()}
+			errs = append(errs, fmt.Errorf("step %s: %w", name, ctx.Err()))
+		}
+	}
+
+	// Todo lo que no llegó a ejecutarse queda como omitido
+	for _, name := range wf.order {
+		if _, done := report.Steps[name]; !done && !skipped[name] {
+			skipped[name] = true
+			report.Skipped = append(report.Skipped, name)
+		}
+	}
+
+	err := errors.Join(errs...)
+	if err == nil && ctx.Err() != nil {
+		err = ctx.Err()
+	}
+
+	report.JobResult = types.JobResult{
+		JobID:     wf.name,
+		JobName:   fmt.Sprintf("workflow-%s", wf.name),
+		Success:   err == nil,
+		Error:     err,
+		Data:      outputs,
+		Attempts:  1,
+		Duration:  time.Since(start),
+		Timestamp: time.Now(),
+	}
+
+	if err != nil {
+		return report, fmt.Errorf("workflow %s failed: %w", wf.name, err)
+	}
+	return report, nil
+}
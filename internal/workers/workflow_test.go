@@ -0,0 +1,189 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"clean-arq-layout/internal/workers/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWorkflow(t *testing.T) {
+	failing := errors.New("step failed")
+
+	tests := []struct {
+		name      string
+		mode      WorkflowMode
+		failStep  string
+		wantErr   bool
+		succeeded []string
+		skipped   []string
+	}{
+		{"all steps succeed", FailFast, "", false, []string{"a", "b", "c", "d"}, nil},
+		{"fail fast skips the rest", FailFast, "a", true, nil, []string{"b", "c", "d"}},
+		{"continue on error skips descendants", ContinueOnError, "b", true, []string{"a", "c"}, []string{"d"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDispatcher(context.Background(), 4, 10)
+			assert.NoError(t, d.Start())
+			defer d.Stop()
+
+			step := func(name string) StepFunc {
+				return func(ctx context.Context, inputs map[string]interface{}) (interface{}, error) {
+					if name == tt.failStep {
+						return nil, failing
+					}
+					return name, nil
+				}
+			}
+
+			// a -> b, a -> c, (b, c) -> d
+			wf := NewWorkflow("test", tt.mode)
+			noRetry := WithStepRetry(types.NoRetry)
+			assert.NoError(t, wf.AddStep("a", step("a"), nil, noRetry))
+			assert.NoError(t, wf.AddStep("b", step("b"), []string{"a"}, noRetry))
+			assert.NoError(t, wf.AddStep("c", step("c"), []string{"a"}, noRetry))
+			assert.NoError(t, wf.AddStep("d", step("d"), []string{"b", "c"}, noRetry))
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			result, err := d.RunWorkflow(ctx, wf)
+
+			if tt.wantErr {
+				assert.ErrorIs(t, err, failing)
+			} else {
+				assert.NoError(t, err)
+			}
+			for _, name := range tt.succeeded {
+				assert.True(t, result.Steps[name].Success, "step %s", name)
+			}
+			assert.ElementsMatch(t, tt.skipped, result.Skipped)
+		})
+	}
+}
+
+func TestRunWorkflowReturnsWhenPoolIsStopped(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	d.Pause()
+	defer d.Stop()
+
+	wf := NewWorkflow("stopped", FailFast)
+	assert.NoError(t, wf.AddStep("a", func(context.Context, map[string]interface{}) (interface{}, error) {
+		return nil, nil
+	}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	returned := make(chan error, 1)
+	go func() {
+		_, err := d.RunWorkflow(ctx, wf)
+		returned <- err
+	}()
+
+	select {
+	case err := <-returned:
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunWorkflow did not return after ctx expired")
+	}
+}
+
+func TestRunWorkflowHonoursCtxOnFullQueue(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 1)
+	assert.NoError(t, d.Start())
+	d.Pause()
+	defer d.Stop()
+	_, err := d.Submit(newTestJob("filler", nil))
+	assert.NoError(t, err)
+
+	wf := NewWorkflow("full", FailFast)
+	assert.NoError(t, wf.AddStep("a", func(context.Context, map[string]interface{}) (interface{}, error) {
+		return nil, nil
+	}, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	returned := make(chan error, 1)
+	go func() {
+		_, err := d.RunWorkflow(ctx, wf)
+		returned <- err
+	}()
+
+	select {
+	case err := <-returned:
+		assert.ErrorIs(t, err, ErrQueueFull)
+	case <-time.After(2 * time.Second):
+		t.Fatal("RunWorkflow did not return while waiting for queue space")
+	}
+}
+
+func TestRunWorkflowCancelsRunningStepsWhenSubmitFails(t *testing.T) {
+	// La cola de b está pausada y llena, así que encolar b falla
+	d := NewDispatcher(context.Background(), 2, 10, WithNamedQueue("narrow", QueueConfig{
+		Workers:     1,
+		Size:        1,
+		Overflow:    OverflowReject,
+		JobPrefixes: []string{"workflow-partial-b"},
+	}))
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+	assert.NoError(t, d.PauseQueue("narrow"))
+	_, err := d.Submit(newTestJob("workflow-partial-b-filler", nil))
+	assert.NoError(t, err)
+
+	wf := NewWorkflow("partial", ContinueOnError)
+	assert.NoError(t, wf.AddStep("slow", func(ctx context.Context, _ map[string]interface{}) (interface{}, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}, nil, WithStepRetry(types.NoRetry)))
+	assert.NoError(t, wf.AddStep("a", func(context.Context, map[string]interface{}) (interface{}, error) {
+		return "a", nil
+	}, nil))
+	assert.NoError(t, wf.AddStep("b", func(context.Context, map[string]interface{}) (interface{}, error) {
+		return "b", nil
+	}, []string{"a"}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	result, err := d.RunWorkflow(ctx, wf)
+	assert.Less(t, time.Since(start), time.Second, "the running step must be cancelled, not awaited")
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.True(t, result.Steps["a"].Success)
+	assert.ErrorIs(t, result.Steps["b"].Error, ErrQueueFull)
+	assert.ErrorIs(t, result.Steps["slow"].Error, context.Canceled)
+}
+
+func TestAddJobStepKeepsRetryPolicy(t *testing.T) {
+	d := NewDispatcher(context.Background(), 1, 10)
+	assert.NoError(t, d.Start())
+	defer d.Stop()
+
+	calls := 0
+	job := &retryJob{
+		testJob: newTestJob("flaky", func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return errors.New("transient")
+			}
+			return nil
+		}),
+		policy: types.ConstantRetry(3, time.Millisecond),
+	}
+
+	wf := NewWorkflow("job-step", FailFast)
+	assert.NoError(t, wf.AddJobStep("a", job, nil))
+
+	result, err := d.RunWorkflow(context.Background(), wf)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Steps["a"].Attempts)
+}